@@ -47,6 +47,14 @@ func TestAddPanic(t *testing.T) {
 			c:   &command.Command{Usage: "HELLO"},
 			msg: `command "failing-app": adding "hello": command name already in use`,
 		},
+		"alias in use": {
+			c:   &command.Command{Usage: "bye", Aliases: []string{"hello"}},
+			msg: `command "failing-app": adding "bye": alias "hello" already in use`,
+		},
+		"name in use as alias": {
+			c:   &command.Command{Usage: "hi"},
+			msg: `command "failing-app": adding "hi": command name already in use`,
+		},
 		"command with other parent": {
 			c:   used,
 			msg: `command "failing-app": adding "used": command has another parent: "other"`,
@@ -88,6 +96,62 @@ func TestAddLoopPanic(t *testing.T) {
 	}
 }
 
+func TestValidateUsagePanic(t *testing.T) {
+	tests := map[string]struct {
+		usage string
+		msg   string
+	}{
+		"valid": {
+			usage: "copy [-r] <source>... <dest>",
+		},
+		"nested brackets": {
+			usage: "log [--level <level>] [<file>...]",
+		},
+		"unclosed bracket": {
+			usage: "copy [-r <source>",
+			msg:   `command "app": adding "copy": invalid usage "copy [-r <source>": unclosed '['`,
+		},
+		"unbalanced bracket": {
+			usage: "copy <source]",
+			msg:   `command "app": adding "copy": invalid usage "copy <source]": unexpected ']'`,
+		},
+		"no leading name": {
+			usage: "[-r] copy",
+			msg:   `command "app": adding "[-r]": invalid usage "[-r] copy": expecting a command name, found "[-r]"`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			msg := func() (msg string) {
+				defer func() {
+					msg = capturePanicMessage(recover())
+				}()
+				app := &command.Command{
+					Usage:         "app",
+					ValidateUsage: true,
+				}
+				app.Add(&command.Command{Usage: test.usage})
+				return ""
+			}()
+			if msg != test.msg {
+				t.Errorf("panic: got %q, want %q", msg, test.msg)
+			}
+		})
+	}
+
+	// without validation
+	app := &command.Command{Usage: "app"}
+	app.Add(&command.Command{Usage: "copy [-r <source>"})
+
+	// a named command without usage
+	app = &command.Command{
+		Usage:         "app",
+		ValidateUsage: true,
+	}
+	app.Add(&command.Command{Name: "copy"})
+}
+
 func appPanic(c *command.Command) (msg string) {
 	defer func() {
 		p := recover()
@@ -95,7 +159,7 @@ func appPanic(c *command.Command) (msg string) {
 	}()
 
 	app := &command.Command{Usage: "failing-app"}
-	app.Add(&command.Command{Usage: "hello"})
+	app.Add(&command.Command{Usage: "hello", Aliases: []string{"hi"}})
 	app.Add(c)
 
 	return ""
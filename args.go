@@ -0,0 +1,137 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// An ArgSpec is the specification
+// of a positional argument
+// as defined in the Command's usage.
+type ArgSpec struct {
+	// Name of the argument,
+	// without brackets.
+	Name string
+
+	// Optional is true if the argument
+	// is enclosed in square brackets.
+	Optional bool
+
+	// Variadic is true if the argument
+	// is followed by '...'.
+	Variadic bool
+}
+
+// ArgSpecs returns the positional arguments
+// defined in the Command's usage.
+// Flags in the usage are ignored.
+func (c *Command) ArgSpecs() []ArgSpec {
+	f := strings.Fields(c.Usage)
+	if len(f) < 2 {
+		return nil
+	}
+
+	var specs []ArgSpec
+	for _, tk := range usageTokens(f[1:]) {
+		var s ArgSpec
+		if strings.HasSuffix(tk, "...") {
+			s.Variadic = true
+			tk = strings.TrimSuffix(tk, "...")
+		}
+		if strings.HasPrefix(tk, "[") && strings.HasSuffix(tk, "]") {
+			s.Optional = true
+			tk = strings.TrimSpace(tk[1 : len(tk)-1])
+		}
+		if strings.HasSuffix(tk, "...") {
+			s.Variadic = true
+			tk = strings.TrimSuffix(tk, "...")
+		}
+		if strings.HasPrefix(tk, "-") {
+			// a flag
+			continue
+		}
+		s.Name = strings.Trim(tk, "<>[] ")
+		if s.Name == "" {
+			continue
+		}
+		specs = append(specs, s)
+	}
+	return specs
+}
+
+// CheckValidArgs returns an usage error
+// if a positional argument is not in ValidArgs,
+// when ValidateArgs is set.
+func (c *Command) checkValidArgs(args []string) error {
+	if !c.ValidateArgs || len(c.ValidArgs) == 0 {
+		return nil
+	}
+	for i, a := range args {
+		if !c.acceptsValidArg(i) {
+			break
+		}
+		if !hasValue(c.ValidArgs, a) {
+			return c.UsageError(fmt.Sprintf("invalid argument %q: must be one of: %s", a, strings.Join(c.ValidArgs, ", ")))
+		}
+	}
+	return nil
+}
+
+// UsageTokens joins the fields of an usage string
+// into tokens with balanced brackets.
+func usageTokens(fields []string) []string {
+	var tokens []string
+	var cur []string
+	depth := 0
+	for _, f := range fields {
+		cur = append(cur, f)
+		depth += strings.Count(f, "[") - strings.Count(f, "]")
+		depth += strings.Count(f, "<") - strings.Count(f, ">")
+		if depth > 0 {
+			continue
+		}
+		tokens = append(tokens, strings.Join(cur, " "))
+		cur = nil
+		depth = 0
+	}
+	if len(cur) > 0 {
+		tokens = append(tokens, strings.Join(cur, " "))
+	}
+	return tokens
+}
+
+// ValidateUsage checks that an usage message
+// starts with a name,
+// and that its brackets are balanced.
+func validateUsage(usage string) error {
+	f := strings.Fields(usage)
+	if len(f) == 0 {
+		return errors.New("empty usage")
+	}
+	if strings.ContainsAny(f[0][:1], "[<-.") {
+		return fmt.Errorf("expecting a command name, found %q", f[0])
+	}
+
+	closing := map[rune]rune{']': '[', '>': '<'}
+	var open []rune
+	for _, r := range usage {
+		switch r {
+		case '[', '<':
+			open = append(open, r)
+		case ']', '>':
+			if len(open) == 0 || open[len(open)-1] != closing[r] {
+				return fmt.Errorf("unexpected %q", r)
+			}
+			open = open[:len(open)-1]
+		}
+	}
+	if len(open) > 0 {
+		return fmt.Errorf("unclosed %q", open[len(open)-1])
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package command_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/js-arias/command"
+)
+
+func TestArgSpecs(t *testing.T) {
+	tests := map[string]struct {
+		usage string
+		specs []command.ArgSpec
+	}{
+		"no arguments": {
+			usage: "cat",
+		},
+		"flags only": {
+			usage: "hello [--utf8] [--message <message>]",
+		},
+		"variadic": {
+			usage: "echo <argument>...",
+			specs: []command.ArgSpec{
+				{Name: "argument", Variadic: true},
+			},
+		},
+		"optional variadic": {
+			usage: "app <command> [<argument>...]",
+			specs: []command.ArgSpec{
+				{Name: "command"},
+				{Name: "argument", Optional: true, Variadic: true},
+			},
+		},
+		"flags and arguments": {
+			usage: "cp [-r] [--mode <mode>] <source> [<destination>]",
+			specs: []command.ArgSpec{
+				{Name: "source"},
+				{Name: "destination", Optional: true},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := &command.Command{Usage: test.usage}
+			specs := c.ArgSpecs()
+			if !reflect.DeepEqual(specs, test.specs) {
+				t.Errorf("usage %q: got %v, want %v", test.usage, specs, test.specs)
+			}
+		})
+	}
+}
+
+func TestValidateArgs(t *testing.T) {
+	newCmd := func(usage string, validate bool) *command.Command {
+		return &command.Command{
+			Usage: usage,
+			Run: func(c *command.Command, args []string) error {
+				fmt.Fprintf(c.Stdout(), "%s", strings.Join(args, " "))
+				return nil
+			},
+			ValidArgs:    []string{"pod", "service"},
+			ValidateArgs: validate,
+		}
+	}
+
+	testExecute(t, newCmd("describe <kind> [<name>]", false), []string{"node"}, "", "node", "")
+	testExecute(t, newCmd("describe <kind> [<name>]", true), []string{"pod", "x"}, "", "pod x", "")
+	testExecute(t, newCmd("describe <kind>...", true), []string{"pod", "service"}, "", "pod service", "")
+	testExecuteError(t, newCmd("describe <kind> [<name>]", true), []string{"node"}, `describe: invalid argument "node": must be one of: pod, service`)
+	testExecuteError(t, newCmd("describe <kind>...", true), []string{"pod", "node"}, `describe: invalid argument "node": must be one of: pod, service`)
+}
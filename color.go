@@ -0,0 +1,63 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"io"
+	"os"
+)
+
+// ANSI escape codes.
+const (
+	ansiBold  = "\x1b[1m"
+	ansiRed   = "\x1b[31m"
+	ansiReset = "\x1b[0m"
+)
+
+// A painter adds ANSI styles to a text
+// if the colors are enabled.
+type painter bool
+
+// Painter returns a painter for the writer w.
+// Colors are enabled if w is a terminal,
+// unless NoColor is set in the root Command
+// or the NO_COLOR environment variable is defined.
+func (c *Command) painter(w io.Writer) painter {
+	if c.root().NoColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return painter(isTerminal(w))
+}
+
+// Bold returns s in bold.
+func (p painter) bold(s string) string {
+	if !p || s == "" {
+		return s
+	}
+	return ansiBold + s + ansiReset
+}
+
+// Red returns s in red.
+func (p painter) red(s string) string {
+	if !p || s == "" {
+		return s
+	}
+	return ansiRed + s + ansiReset
+}
+
+// IsTerminal returns true
+// if v (a reader or a writer)
+// is a terminal.
+func isTerminal(v any) bool {
+	f, ok := v.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
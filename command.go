@@ -38,21 +38,34 @@
 //
 // To run a command with a given set of arguments,
 // use the method Execute.
+//
+// The root command accepts the --help-all flag
+// to print the help of all the commands in the tree.
 package command
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
-	"unicode"
-	"unicode/utf8"
+	"time"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/transform"
 )
 
+// A RunFunc is the signature
+// of the Run function of a Command.
+type RunFunc = func(c *Command, args []string) error
+
 // A Command is a command in an application
 // like 'run' in 'go run'.
 //
@@ -72,9 +85,27 @@ type Command struct {
 	//	    of the previous argument can be specified.
 	//
 	// The first word of the usage message
-	// is taken to be the Command's name.
+	// is taken to be the Command's name,
+	// unless Name is defined.
 	Usage string
 
+	// Name, if set,
+	// is the Command's name,
+	// used to find the Command
+	// instead of the first word of the usage message.
+	// It must be set before adding the Command
+	// to its parent.
+	Name string
+
+	// Aliases are alternative names
+	// of the Command.
+	// They can be used instead of the Command's name
+	// to execute the Command,
+	// or to request its help.
+	// Aliases must be set before adding the Command
+	// to its parent.
+	Aliases []string
+
 	// Short is a short description
 	// (on a single line)
 	// of the Command.
@@ -84,18 +115,526 @@ type Command struct {
 	// of the Command.
 	Long string
 
+	// Examples are usage examples
+	// of the Command,
+	// printed in its own section
+	// after the long description.
+	Examples string
+
+	// HelpFooter is a text printed
+	// at the end of the Command's help,
+	// for example,
+	// a link to an online documentation.
+	HelpFooter string
+
+	// Version is the version of the application.
+	// If it is defined in the root Command,
+	// the root Command accepts the --version flag,
+	// and the version command
+	// (unless there is a child Command
+	// with that name),
+	// to print the application's version.
+	Version string
+
 	// Run runs the Command.
 	// The args are the unparsed arguments.
+	// If the Command has children,
+	// and the first argument is the name
+	// of a child,
+	// the child is executed instead.
 	Run func(c *Command, args []string) error
 
+	// PreRun and PostRun are hooks
+	// called by Execute
+	// immediately before and after Run.
+	// If PreRun returns an error,
+	// neither Run nor PostRun are called,
+	// and the error is returned.
+	// PostRun is always called after Run,
+	// but if Run returns an error,
+	// the error from Run takes precedence
+	// over the error from PostRun.
+	PreRun  func(c *Command, args []string) error
+	PostRun func(c *Command, args []string) error
+
+	// Timeout, if not zero,
+	// is the maximum duration of Run.
+	// The context returned by the Command's Context method
+	// is canceled after the timeout,
+	// and Execute returns ErrDeadlineExceeded.
+	// Run must honor the context
+	// for the timeout to take effect.
+	Timeout time.Duration
+
+	// PersistentPreRun is a hook
+	// called before the PreRun of the Command
+	// and of all of its descendants.
+	// When a Command is executed,
+	// the PersistentPreRun functions
+	// of all of its ancestors,
+	// and the Command itself,
+	// are called in order,
+	// starting from the root.
+	// The Command passed to the function
+	// is the executed Command.
+	// If any of them returns an error,
+	// the execution stops
+	// and the error is returned.
+	PersistentPreRun func(c *Command, args []string) error
+
 	// SetFlags is the function used
 	// to define the flags specific to the command.
 	// Use method Flags to retrieve
 	// the FlagSet of the command.
 	SetFlags func(c *Command)
 
+	// If RawRunErrors is true,
+	// the errors returned by Run
+	// are returned by Execute as they are.
+	// By default,
+	// the errors are prefixed with the Command's long name.
+	// It is only used in the root Command.
+	RawRunErrors bool
+
+	// OutputFilter, if set,
+	// captures everything written by Run
+	// into the Command's standard output,
+	// and pass it through the filter
+	// before writing it into the actual standard output.
+	// If Run returns an error,
+	// the captured output is written without filtering.
+	//
+	// As the whole output is kept in memory
+	// until Run ends,
+	// for commands with large outputs
+	// use OutputWrapper instead.
+	OutputFilter func(c *Command, raw []byte) ([]byte, error)
+
+	// OutputWrapper, if set,
+	// wraps the Command's standard output
+	// while Run is executed.
+	// It is the streaming alternative of OutputFilter.
+	// The returned writer is closed
+	// after Run ends.
+	// If both OutputFilter and OutputWrapper are set,
+	// the filtered output is written into the wrapper.
+	OutputWrapper func(c *Command, w io.Writer) io.WriteCloser
+
+	// ValidArgs is the list of valid values
+	// for the first positional argument of the Command.
+	// It is used for shell completion,
+	// and if ValidateArgs is true,
+	// to validate the arguments.
+	// If the first positional argument in the Command's usage
+	// is variadic (i.e. it is followed by '...'),
+	// the values are valid for all positional arguments.
+	ValidArgs []string
+
+	// ValidateArgs, if true,
+	// makes the Command to return an usage error
+	// if a positional argument
+	// that takes its value from ValidArgs
+	// is not in ValidArgs.
+	ValidateArgs bool
+
+	// ValidArgsFunc, if set,
+	// returns the completion suggestions
+	// for the positional arguments of the Command.
+	// Args are the positional arguments already given,
+	// and toComplete is the partial argument
+	// to be completed.
+	ValidArgsFunc func(c *Command, args []string, toComplete string) []string
+
+	// DynamicCompletion, if true,
+	// makes the completion scripts
+	// to call the application
+	// (with the hidden __complete command)
+	// to get the completion candidates,
+	// instead of using a static list
+	// of commands and flags.
+	// It is only used in the root Command.
+	DynamicCompletion bool
+
+	// RequiresNetwork indicates that the Command
+	// requires network access.
+	// If any Command in the tree requires network access,
+	// all the commands accept the --offline flag,
+	// that is inherited by the children commands.
+	// If the flag is set,
+	// Execute returns an usage error
+	// when a Command that requires network is invoked.
+	RequiresNetwork bool
+
+	// DisableFlagParsing, if true,
+	// disables the parsing of the flags
+	// of a runnable Command,
+	// so all the arguments,
+	// including flags,
+	// the help flag,
+	// and names of children commands,
+	// are passed to Run unchanged.
+	// It is useful for commands
+	// that pass their arguments
+	// to an external process.
+	// SetFlags is not called.
+	DisableFlagParsing bool
+
+	// If Hidden is true,
+	// the Command is not listed in the help
+	// of its parent,
+	// but it can be still executed,
+	// and its help can be requested explicitly.
+	Hidden bool
+
+	// Default, if set,
+	// is the name of the child command
+	// executed when the Command
+	// is called without arguments.
+	// If empty,
+	// the help of the Command is printed.
+	Default string
+
+	// UnknownCommandFunc, if set,
+	// is called when the first argument
+	// of a Command with children
+	// is not the name of a child,
+	// instead of returning an unknown command error.
+	// The name is the first argument,
+	// and args are the arguments after it.
+	// It can be used to run commands
+	// discovered at run time,
+	// for example,
+	// external executables.
+	UnknownCommandFunc func(c *Command, name string, args []string) error
+
+	// Group is the name of the group
+	// in which the Command is listed
+	// in the help of its parent,
+	// for example "Management Commands".
+	// Commands without a group
+	// are listed in the default group.
+	Group string
+
+	// GroupOrder is the order
+	// in which the groups of the children commands
+	// are printed in the help.
+	// Groups not in the list
+	// are printed after the listed groups
+	// in lexicographic order.
+	GroupOrder []string
+
+	// Deprecated, if set,
+	// is the deprecation message of the Command,
+	// for example `use "pull" instead`.
+	// A deprecated Command is not listed
+	// in the help of its parent,
+	// and when executed,
+	// it prints a warning in the standard error
+	// before running.
+	Deprecated string
+
+	// AllowPrefixMatch, if true,
+	// allows the use of an unambiguous prefix
+	// of a command name,
+	// for example "app hel" for "app hello".
+	// It is only used in the root Command.
+	AllowPrefixMatch bool
+
+	// InterspersedFlags, if true,
+	// allows flags after the positional arguments
+	// of the commands without children,
+	// for example "app hello world --utf8".
+	// The positional arguments are kept in its original order,
+	// and any argument after a "--" terminator
+	// is taken as a positional argument.
+	// It is only used in the root Command.
+	InterspersedFlags bool
+
+	// TraverseChildren, if true,
+	// passes the help flag
+	// given before the name of a child command
+	// to that child,
+	// so "app -h cmd" prints the help of "app cmd",
+	// while the other flags before the child name
+	// are parsed by the parent,
+	// as in "app --verbose cmd".
+	// It is only used in the root Command.
+	TraverseChildren bool
+
+	// AllowResponseFiles, if true,
+	// allows the use of response files
+	// in the command line.
+	// An argument that starts with '@',
+	// as in "@args.txt",
+	// is replaced by the arguments
+	// read from the named file.
+	// The arguments in the file
+	// are separated by spaces,
+	// and quotes can be used
+	// to include spaces in an argument.
+	// Response files can include other response files.
+	// Arguments after a "--" terminator
+	// are not expanded.
+	// It is only used in the root Command.
+	AllowResponseFiles bool
+
+	// NoColor, if true,
+	// disables the colors in the help
+	// and error messages
+	// printed in a terminal.
+	// Colors are also disabled
+	// if the NO_COLOR environment variable
+	// is defined.
+	// It is only used in the root Command.
+	NoColor bool
+
+	// ValidateUsage, if true,
+	// checks the usage message of the children commands
+	// when they are added,
+	// and Add panics if the usage does not start
+	// with a name
+	// (for example, if it starts with a flag),
+	// or if the brackets are not balanced.
+	// An empty usage is valid
+	// if the child has a Name.
+	// It is only used in the root Command,
+	// and it should be set before adding any child.
+	ValidateUsage bool
+
+	// FoldFunc is the function used
+	// to normalize the names of the commands
+	// for case-insensitive matching.
+	// By default it is strings.ToLower.
+	// It is only used in the root Command,
+	// and it should be set before adding any child.
+	FoldFunc func(string) string
+
+	// Decoders are the decoding functions
+	// used by DecodeStdin,
+	// indexed by the name of the format
+	// (for example "yaml" or "toml").
+	// A JSON decoder is always available.
+	// It is only used in the root Command.
+	Decoders map[string]func(data []byte, v any) error
+
+	// FeatureGate, if set,
+	// reports whether a Command is available.
+	// Commands that are not available
+	// are not shown in the help,
+	// and return an error when they are invoked.
+	// The gate is evaluated once per Command
+	// in each call to Execute.
+	// It is only used in the root Command.
+	FeatureGate func(c *Command) bool
+
+	// UsageFunc, if set,
+	// returns the usage line of a Command,
+	// printed when the help flag is used,
+	// or when there is an usage error.
+	// It is used by the Command
+	// and its descendants.
+	UsageFunc func(c *Command) string
+
+	// TitleFunc, if set,
+	// is used to format the short description
+	// of a Command
+	// as the title of its help,
+	// for example,
+	// to keep the sentence case intact.
+	// By default,
+	// only the first letter
+	// is changed to title case.
+	// It is used by the Command
+	// and its descendants.
+	TitleFunc func(string) string
+
+	// Messages, if set,
+	// are the texts used in the help
+	// and error messages
+	// of the Command and its descendants,
+	// for example,
+	// to localize them.
+	Messages *Messages
+
+	// HelpWidth is the maximum width
+	// of the help text.
+	// Longer lines are wrapped,
+	// except the usage line.
+	// If zero,
+	// and the help is printed in a terminal,
+	// the width is taken from the COLUMNS
+	// environment variable,
+	// or from the terminal size.
+	// Otherwise the width is 80.
+	// It is only used in the root Command.
+	HelpWidth int
+
+	// HelpCommandName is the name of the keyword
+	// used to request the help of a command,
+	// as in "app help cmd".
+	// By default is "help".
+	// It is only used in the root Command.
+	HelpCommandName string
+
+	// DisableAutoHelp, if true,
+	// disables the automatic help.
+	// The help keyword is not handled by Execute,
+	// so a child command with that name
+	// can be used instead,
+	// and the -h and --help flags,
+	// if not defined by a Command,
+	// are reported as undefined flags
+	// (instead of printing the help
+	// when the flag package returns flag.ErrHelp).
+	// It is only used in the root Command.
+	DisableAutoHelp bool
+
+	// HelpOnStdout, if true,
+	// prints the help of a command with children
+	// executed without arguments
+	// in the standard output
+	// (instead of the standard error).
+	// It is only used in the root Command.
+	HelpOnStdout bool
+
+	// UsePager, if true,
+	// prints the help through the pager
+	// defined in the PAGER environment variable,
+	// if the standard output is a terminal.
+	// It is only used in the root Command.
+	UsePager bool
+
+	// ConfigFunc, if set,
+	// returns the values of the flags
+	// read from a configuration file,
+	// as a map of flag names to values.
+	// It is called after the flags are parsed,
+	// and the values are only used
+	// for the flags that are not set
+	// in the command line
+	// or by an environment variable.
+	// Names that are not flags of the Command
+	// are ignored.
+	// It is used by the Command
+	// and its descendants.
+	ConfigFunc func(c *Command) (map[string]string, error)
+
+	// ErrorHandler, if set,
+	// is called by Main
+	// when Execute returns an error,
+	// instead of printing the error
+	// and finishing the application.
+	// It is only used in the root Command.
+	ErrorHandler func(c *Command, err error)
+
+	// ErrorPrefix is a text printed by Main
+	// before the error message,
+	// for example "fatal: ".
+	// The line printed after an usage error
+	// with the reference to the help
+	// is defined in Messages.Details.
+	// It is only used in the root Command.
+	ErrorPrefix string
+
+	// SilenceUsage, if true,
+	// prevents Main from printing the usage line,
+	// the hint,
+	// and the help reference
+	// after an usage error.
+	// It is only used in the root Command.
+	SilenceUsage bool
+
+	// SilenceErrors, if true,
+	// prevents Main from printing the error
+	// returned by Execute,
+	// and prevents Execute from printing
+	// the deprecation warnings.
+	// The error is still returned by Execute,
+	// and Main still finishes the application
+	// with the error exit code.
+	// It is only used in the root Command.
+	SilenceErrors bool
+
+	// ErrorFormat is the format
+	// used by Main to print the errors.
+	// It can be "text" (the default),
+	// or "json",
+	// that prints the error
+	// as a JSON object
+	// with the command path ("command"),
+	// the error message ("error"),
+	// and, in usage errors,
+	// the usage line of the command ("usage"),
+	// as printed in the text format.
+	// It is only used in the root Command.
+	ErrorFormat string
+
+	// Trace, if set,
+	// is called after each phase
+	// of the execution of a Command,
+	// with the name of the phase
+	// ("parse", "prerun", "run", or "postrun"),
+	// the Command,
+	// and the duration of the phase.
+	// It is only used in the root Command.
+	Trace func(event string, c *Command, d time.Duration)
+
+	// Logger, if set,
+	// is used to log the execution of the commands.
+	// The command path,
+	// the parsed flags and arguments,
+	// and the end of the execution
+	// are logged at debug level,
+	// and errors are logged at error level.
+	// It is used by the Command
+	// and its descendants.
+	Logger *slog.Logger
+
+	// FlagNormalizeFunc, if set,
+	// normalizes the names of the flags,
+	// so different spellings of a flag name
+	// (for example "--dry_run", "--dry-run", and "--dryRun")
+	// are taken as the same flag.
+	// A flag in the command line
+	// matches a defined flag
+	// if both names have the same normalized form.
+	// It is used by the Command
+	// and its descendants.
+	FlagNormalizeFunc func(name string) string
+
+	// FlagErrorFunc, if set,
+	// is called when there is an error
+	// parsing the flags of a Command,
+	// and the returned error is returned by Execute.
+	// To keep the error as an usage error,
+	// the function should return
+	// an error created with c.UsageError.
+	// It is used by the Command
+	// and its descendants.
+	FlagErrorFunc func(c *Command, err error) error
+
 	flags *flag.FlagSet
 
+	// persistent flags
+	pflags *flag.FlagSet
+
+	// names of the flags
+	// defined with a shorthand,
+	// indexed by the shorthand
+	shorthands map[string]string
+
+	// names of the persistent flags
+	// inherited from the ancestors
+	inherited map[string]bool
+
+	// arguments received by the Command
+	// before parsing the flags
+	rawArgs []string
+
+	// flags set in the command line
+	// in command line order
+	flagOrder []string
+
 	// Stdin specifies the Command's standard input
 	stdin io.Reader
 
@@ -104,20 +643,75 @@ type Command struct {
 	stdout io.Writer
 	stderr io.Writer
 
+	// encodings of the standard input and output
+	inEnc  encoding.Encoding
+	outEnc encoding.Encoding
+
 	parent *Command
 
+	// output of Explain
+	explain io.Writer
+
+	// audit logger
+	audit func(path []string, args []string, err error)
+
+	// Command that returned the error
+	// of the last Execute call
+	failed *Command
+
+	// deepest Command reached during an Execute call
+	// and its arguments
+	leaf     *Command
+	leafArgs []string
+
+	// results of the feature gate
+	// during an Execute call
+	gates map[*Command]bool
+
+	// context of an Execute call
+	ctx context.Context
+
+	// environment variables bound to flags
+	envVars map[string]string
+
+	// deprecated flags
+	// and flag values
+	deprecatedFlags  map[string]string
+	deprecatedValues map[string]map[string]string
+
+	// groups of flags required together
+	requiredTogether [][]string
+
+	// groups of flags in the help
+	flagGroups []flagGroup
+
+	// valid values of flags
+	flagValues map[string][]string
+
+	// values set with SetValue
+	values map[any]any
+
+	// middleware added with Use
+	middleware []func(next RunFunc) RunFunc
+
 	// children commands
-	mu       sync.Mutex
+	mu       sync.RWMutex
 	commands map[string]*Command
+	aliases  map[string]string
 }
 
 // Add adds a child command to a Command.
 // This function panics if the child command is invalid:
-//	* because it is nil
-//	* because it does not have a name
-//	* because there is a child command with the same name
-//	* because the child already has a parent
-//	* because the command is already a child of the child command
+//   - because it is nil
+//   - because it does not have a name
+//   - because there is a child command with the same name
+//   - because an alias of the child is already in use
+//   - because the child already has a parent
+//   - because the command is already a child of the child command
+//   - because the usage of the child is malformed
+//     (only if ValidateUsage is set)
+//
+// Add can be called while the Command is executed.
 func (c *Command) Add(child *Command) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -133,7 +727,7 @@ func (c *Command) Add(child *Command) {
 		}
 	}
 
-	name := child.name()
+	name := c.fold(child.rawName())
 	if name == "" {
 		msg := fmt.Sprintf("command %q: adding a command without usage", c.longName())
 		panic(msg)
@@ -142,75 +736,301 @@ func (c *Command) Add(child *Command) {
 		msg := fmt.Sprintf("command %q: adding %q: command name already in use", c.longName(), name)
 		panic(msg)
 	}
+	if _, dup := c.aliases[name]; dup {
+		msg := fmt.Sprintf("command %q: adding %q: command name already in use", c.longName(), name)
+		panic(msg)
+	}
 	if child.parent != nil {
 		msg := fmt.Sprintf("command %q: adding %q: command has another parent: %q", c.longName(), name, child.parent.longName())
 		panic(msg)
 	}
+	// a named command can have an empty usage
+	if c.root().ValidateUsage && child.Usage != "" {
+		if err := validateUsage(child.Usage); err != nil {
+			msg := fmt.Sprintf("command %q: adding %q: invalid usage %q: %v", c.longName(), name, child.Usage, err)
+			panic(msg)
+		}
+	}
+	aliases := make(map[string]bool, len(child.Aliases))
+	for _, a := range child.Aliases {
+		a = c.fold(a)
+		_, dupCmd := c.commands[a]
+		_, dupAlias := c.aliases[a]
+		if a == name || aliases[a] || dupCmd || dupAlias {
+			msg := fmt.Sprintf("command %q: adding %q: alias %q already in use", c.longName(), name, a)
+			panic(msg)
+		}
+		aliases[a] = true
+	}
 
 	if c.commands == nil {
 		c.commands = make(map[string]*Command)
 	}
 	c.commands[name] = child
 	child.parent = c
+
+	if len(aliases) == 0 {
+		return
+	}
+	if c.aliases == nil {
+		c.aliases = make(map[string]string)
+	}
+	for a := range aliases {
+		c.aliases[a] = name
+	}
+}
+
+// Remove removes a child command from a Command.
+// The removed command can be added again
+// to any Command.
+// If child is not a child of the Command,
+// Remove does nothing.
+//
+// Add and Remove can be called
+// while the Command is executed,
+// but the removed command must not be running.
+func (c *Command) Remove(child *Command) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if child == nil || child.parent != c {
+		return
+	}
+	name := c.fold(child.rawName())
+	if c.commands[name] != child {
+		return
+	}
+	delete(c.commands, name)
+	for a, canon := range c.aliases {
+		if canon == name {
+			delete(c.aliases, a)
+		}
+	}
+	child.parent = nil
 }
 
 // Execute executes the Command
 // with the arguments after the Command's name.
-func (c *Command) Execute(args []string) error {
-	// initialize flags
-	c.flags = flag.NewFlagSet(c.name(), flag.ContinueOnError)
-	c.flags.SetOutput(io.Discard) // do not print flag errors
-	c.flags.Usage = func() {}
-	if c.SetFlags != nil {
-		c.SetFlags(c)
+func (c *Command) Execute(args []string) (err error) {
+	r := c.root()
+
+	// restore the state of the execution,
+	// as Execute can be called from a Run function
+	gates, leaf, leafArgs := r.gates, r.leaf, r.leafArgs
+	r.gates = make(map[*Command]bool)
+	defer func() {
+		r.failed = nil
+		if err != nil {
+			r.failed = r.leaf
+		}
+		r.gates, r.leaf, r.leafArgs = gates, leaf, leafArgs
+	}()
+
+	if c.parent == nil && len(args) > 0 && args[0] == completeCmd {
+		return c.complete(args[1:])
+	}
+
+	if r.AllowResponseFiles {
+		if args, err = c.expandResponseFiles(args); err != nil {
+			return err
+		}
+	}
+
+	if lg := c.logger(); lg != nil {
+		lg.Debug("execute", "command", c.longName(), "args", args)
+		defer func() {
+			leaf := r.leaf
+			if leaf == nil {
+				leaf = c
+			}
+			if err != nil {
+				lg.Error("execution failed", "command", leaf.longName(), "error", err)
+				return
+			}
+			lg.Debug("execution finished", "command", leaf.longName())
+		}()
+	}
+
+	if r.audit != nil && r.explain == nil {
+		defer func() {
+			leaf := r.leaf
+			if leaf == nil {
+				leaf = c
+			}
+			r.audit(leaf.path(), append([]string(nil), r.leafArgs...), err)
+		}()
+	}
+	return c.execute(args)
+}
+
+// Execute executes the Command
+// with the arguments after the Command's name,
+// as part of a call to Execute.
+func (c *Command) execute(args []string) error {
+	r := c.root()
+	r.leaf = c
+	r.leafArgs = args
+
+	endParse := c.startTrace("parse")
+	c.rawArgs = args
+	c.flagOrder = nil
+	if c.DisableFlagParsing && c.Run != nil {
+		c.flags = flag.NewFlagSet(c.name(), flag.ContinueOnError)
+		endParse()
+		return c.runCommand(args)
+	}
+	c.initFlags()
+	if c.parent == nil && !c.DisableAutoHelp && c.flags.Lookup(helpAllFlagName) == nil {
+		// not listed in the help
+		c.flags.Var(new(helpAllFlag), helpAllFlagName, "")
 	}
 
 	// parse flags
+	if c.root().InterspersedFlags && !c.hasChildren() {
+		args = c.intersperse(args)
+	}
+	if c.root().TraverseChildren && c.hasChildren() {
+		args = c.traverse(args)
+	}
+	args = c.expandCounts(args)
+	args = c.normalizeFlags(args)
 	err := c.flags.Parse(args)
+	endParse()
+	if errors.Is(err, flag.ErrHelp) && c.root().DisableAutoHelp {
+		err = fmt.Errorf("flag provided but not defined: %s", helpFlagArg(args))
+	}
 	if errors.Is(err, flag.ErrHelp) {
-		if c.hasChildren() {
-			help(c.Stderr(), c)
+		if c.hasChildren() || c.Run == nil {
+			if c.explained("print help of %s", c.longName()) {
+				return nil
+			}
+			c.PrintHelp()
 			return nil
 		}
-		if c.Run == nil {
-			help(c.Stdout(), c)
+		if c.explained("print usage of %s", c.longName()) {
 			return nil
 		}
-		c.usage(c.Stderr())
+		c.PrintUsage()
 		return nil
 	}
 	if err != nil {
-		return c.UsageError(err.Error())
+		return c.flagError(err)
+	}
+	c.flagOrder = c.parsedOrder(args)
+	if err := c.setFromEnv(); err != nil {
+		return err
+	}
+	if err := c.setFromConfig(); err != nil {
+		return err
 	}
+	if err := c.checkFlags(); err != nil {
+		return err
+	}
+	terminated := c.terminated(args)
 	args = c.flags.Args()
+	if lg := c.logger(); lg != nil {
+		lg.Debug("flags parsed", "command", c.longName(), "flags", c.flagsString(), "args", args)
+	}
 
-	// run the command
-	if c.Run != nil {
-		err := c.Run(c, args)
-		if errors.Is(err, usageError{}) {
-			return err
+	if f := c.flags.Lookup(versionFlagName); f != nil {
+		if v, ok := f.Value.(*versionFlag); ok && bool(*v) {
+			if c.explained("print version of %s", c.name()) {
+				return nil
+			}
+			c.printVersion()
+			return nil
 		}
-		if err != nil {
-			return fmt.Errorf("%s: %v", c.longName(), err)
+	}
+
+	if f := c.flags.Lookup(helpAllFlagName); f != nil {
+		if v, ok := f.Value.(*helpAllFlag); ok && bool(*v) {
+			if c.explained("print help of all commands of %s", c.name()) {
+				return nil
+			}
+			helpAll(c.Stdout(), c)
+			return nil
 		}
-		return nil
+	}
+
+	// a runnable command with children
+	// runs a child if it is the first argument
+	if c.Run != nil && len(args) > 0 && !terminated {
+		if child, ok := c.child(args[0]); ok {
+			if !child.available() {
+				return fmt.Errorf("%s: not available in your edition", child.longName())
+			}
+			return child.execute(args[1:])
+		}
+	}
+
+	// run the command
+	if c.Run != nil {
+		return c.runCommand(args)
 	}
 
 	// non runnable command
 	if !c.hasChildren() {
-		return c.UsageError("unknown command")
+		return usageError{
+			c:   c,
+			msg: fmt.Sprintf("%s: %s", c.longName(), c.messages().UnknownCommand),
+			err: ErrUnknownCommand,
+		}
 	}
 
+	if len(args) == 0 && c.Default != "" {
+		child, ok := c.child(c.Default)
+		if !ok {
+			return fmt.Errorf("%s: default command %q not found", c.longName(), c.Default)
+		}
+		if !child.available() {
+			return fmt.Errorf("%s: not available in your edition", child.longName())
+		}
+		return child.execute(nil)
+	}
 	if len(args) == 0 {
+		if c.explained("print help of %s", c.longName()) {
+			return nil
+		}
+		if c.root().HelpOnStdout {
+			c.pagedHelp()
+			return nil
+		}
 		help(c.Stderr(), c)
 		return nil
 	}
+	if terminated {
+		// arguments after "--" are never commands
+		return c.UsageError(fmt.Sprintf("unexpected argument %q after --", args[0]))
+	}
 	child, ok := c.child(args[0])
+	if !ok && !c.isHelp(args[0]) && !c.isVersion(args[0]) {
+		// built-in keywords are never prefixes
+		var err error
+		if child, err = c.prefixMatch(args[0]); err != nil {
+			return err
+		}
+		ok = child != nil
+	}
 	if !ok {
-		if strings.ToLower(args[0]) != "help" {
+		if c.isVersion(args[0]) {
+			if c.explained("print version of %s", c.name()) {
+				return nil
+			}
+			c.printVersion()
+			return nil
+		}
+		if !c.isHelp(args[0]) && c.UnknownCommandFunc != nil {
+			return c.UnknownCommandFunc(c, args[0], args[1:])
+		}
+		if !c.isHelp(args[0]) {
+			msg := fmt.Sprintf("%s %s: %s", c.longName(), args[0], c.messages().UnknownCommand)
+			if dym := didYouMean(c.suggestions(args[0], false)); dym != "" {
+				msg += ". " + dym
+			}
 			return usageError{
 				c:   c,
-				msg: fmt.Sprintf("%s %s: unknown command", c.longName(), args[0]),
+				msg: msg,
+				err: ErrUnknownCommand,
 			}
 		}
 		if err := c.help(args[1:]); err != nil {
@@ -218,13 +1038,102 @@ func (c *Command) Execute(args []string) error {
 		}
 		return nil
 	}
-	if err := child.Execute(args[1:]); err != nil {
+	if !child.available() {
+		return fmt.Errorf("%s: not available in your edition", child.longName())
+	}
+	if err := child.execute(args[1:]); err != nil {
 		return err
 	}
 	return nil
 }
 
-//Flags returns the current flag set of the Command.
+// RunCommand runs a runnable Command
+// with its hooks,
+// after its flags are parsed.
+func (c *Command) runCommand(args []string) error {
+	if c.RequiresNetwork && c.offline() {
+		return c.UsageError("this command requires network access; remove --offline")
+	}
+	if err := c.checkValidArgs(args); err != nil {
+		return err
+	}
+	if c.explained("run: %s with flags %s and args %v", c.longName(), c.flagsString(), args) {
+		return nil
+	}
+	if c.Deprecated != "" {
+		c.warn("Command %q is deprecated: %s", c.name(), c.Deprecated)
+	}
+	endPreRun := c.startTrace("prerun")
+	if err := c.persistentPreRun(args); err != nil {
+		return c.runError(err)
+	}
+	if c.PreRun != nil {
+		if err := c.PreRun(c, args); err != nil {
+			return c.runError(err)
+		}
+	}
+	endPreRun()
+
+	endRun := c.startTrace("run")
+	var err error
+	if c.Timeout > 0 {
+		err = c.runWithTimeout(args)
+	} else {
+		err = c.run(args)
+	}
+	endRun()
+
+	if c.PostRun != nil {
+		endPostRun := c.startTrace("postrun")
+		if pErr := c.PostRun(c, args); err == nil {
+			err = pErr
+		}
+		endPostRun()
+	}
+	return c.runError(err)
+}
+
+// ExecuteResult executes a Command
+// with the given arguments,
+// capturing its standard output,
+// and returns the output decoded
+// with the decode function.
+// Commands with an explicit standard output
+// (set with SetStdout)
+// will not be captured.
+func ExecuteResult[T any](c *Command, args []string, decode func([]byte) (T, error)) (T, error) {
+	out, err := c.capture(args)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return decode(out)
+}
+
+// Explain resolves the Command to be executed
+// with the given arguments,
+// and parses its flags,
+// but instead of running the Command,
+// it writes into w
+// a description of what would be done.
+// It is useful to debug the parsing of a command line.
+func (c *Command) Explain(args []string, w io.Writer) error {
+	r := c.root()
+	r.explain = w
+	defer func() {
+		r.explain = nil
+	}()
+	return c.Execute(args)
+}
+
+// RawArgs returns a copy of the arguments
+// received by the Command
+// before the flags were parsed.
+func (c *Command) RawArgs() []string {
+	return append([]string(nil), c.rawArgs...)
+}
+
+// Flags returns the current flag set of the Command.
 func (c *Command) Flags() *flag.FlagSet {
 	return c.flags
 }
@@ -235,6 +1144,10 @@ func (c *Command) Flags() *flag.FlagSet {
 // it will print the error
 // in the programs' standard error,
 // and finish the application.
+// The exit code is 1,
+// unless the error is an ExitError.
+// If ErrorHandler is set,
+// the error is passed to the handler instead.
 //
 // Main will panic if the Command is not a root Command.
 func (c *Command) Main() {
@@ -244,17 +1157,142 @@ func (c *Command) Main() {
 	}
 
 	err := c.Execute(os.Args[1:])
-	if errors.Is(err, usageError{}) {
-		fmt.Fprintf(c.Stderr(), "%v\n", err)
-		from := err.(usageError).c
-		from.usage(c.Stderr())
-		fmt.Fprintf(c.Stderr(), "Run %q for details.\n", from.helpPath())
-		os.Exit(1)
+	if err == nil {
+		return
 	}
-	if err != nil {
-		fmt.Fprintf(c.Stderr(), "%v.\n", err)
+	if c.ErrorHandler != nil {
+		c.ErrorHandler(c, err)
+		return
+	}
+	if c.ErrorFormat == "json" {
+		if !c.SilenceErrors {
+			c.printJSONError(err)
+		}
+		os.Exit(exitCode(err))
+	}
+	var ue usageError
+	if errors.As(err, &ue) {
+		if !c.SilenceErrors {
+			fmt.Fprintf(c.Stderr(), "%s\n", c.painter(c.Stderr()).red(c.ErrorPrefix+err.Error()))
+		}
+		if !c.SilenceUsage {
+			if hint := UsageHint(err); hint != "" {
+				fmt.Fprintf(c.Stderr(), "%s\n", hint)
+			}
+			from := ue.c
+			from.usage(c.Stderr())
+			fmt.Fprintf(c.Stderr(), from.messages().Details+"\n", from.helpPath())
+		}
 		os.Exit(1)
 	}
+	if !c.SilenceErrors {
+		fmt.Fprintf(c.Stderr(), "%s\n", c.painter(c.Stderr()).red(c.ErrorPrefix+err.Error()+"."))
+	}
+	os.Exit(exitCode(err))
+}
+
+// SetAuditLogger sets a function
+// called after each call to Execute
+// of the root Command,
+// with the path of names
+// from the root to the Command that was executed,
+// the arguments received by that Command,
+// and the error returned by Execute
+// (nil on success).
+// It is intended for auditing who-ran-what,
+// for example using syslog.
+func (c *Command) SetAuditLogger(fn func(path []string, args []string, err error)) {
+	c.audit = fn
+}
+
+// SetInputEncoding sets the encoding
+// of the Command's standard input.
+// During the execution of the Run function,
+// the input will be decoded from enc into UTF-8.
+// The encoding is used by the Command
+// and its descendants.
+// If enc is nil,
+// the input bytes are read unchanged.
+func (c *Command) SetInputEncoding(enc encoding.Encoding) {
+	c.inEnc = enc
+}
+
+// SetOutputEncoding sets the encoding
+// of the Command's standard output.
+// During the execution of the Run function,
+// the output will be encoded from UTF-8 into enc.
+// The encoding is used by the Command
+// and its descendants.
+// If enc is nil,
+// the output bytes are written unchanged.
+func (c *Command) SetOutputEncoding(enc encoding.Encoding) {
+	c.outEnc = enc
+}
+
+// SetIO sets the Command's standard input,
+// output,
+// and error.
+func (c *Command) SetIO(in io.Reader, out, err io.Writer) {
+	c.stdin = in
+	c.stdout = out
+	c.stderr = err
+}
+
+// IO returns the Command's standard input,
+// output,
+// and error,
+// as returned by Stdin,
+// Stdout,
+// and Stderr.
+func (c *Command) IO() (io.Reader, io.Writer, io.Writer) {
+	return c.Stdin(), c.Stdout(), c.Stderr()
+}
+
+// Use adds a middleware
+// that wraps the Run function
+// of every Command executed
+// from the root Command,
+// for example,
+// to time the execution
+// or to recover from panics.
+// The middleware are applied
+// in the order in which they were added,
+// so the first middleware
+// is the outermost one.
+// The hooks (PreRun, PostRun)
+// are not wrapped.
+//
+// It is only used in the root Command.
+func (c *Command) Use(mw func(next RunFunc) RunFunc) {
+	c.middleware = append(c.middleware, mw)
+}
+
+// SetValue stores a value in the Command
+// with the given key,
+// for example,
+// a configuration object set in PreRun
+// and used in Run.
+// The value is visible to the Command
+// and its descendants.
+func (c *Command) SetValue(key, val any) {
+	if c.values == nil {
+		c.values = make(map[any]any)
+	}
+	c.values[key] = val
+}
+
+// Value returns the value stored
+// with the given key
+// in the Command,
+// or in its closest ancestor
+// with that key.
+func (c *Command) Value(key any) (any, bool) {
+	for p := c; p != nil; p = p.parent {
+		if v, ok := p.values[key]; ok {
+			return v, true
+		}
+	}
+	return nil, false
 }
 
 // SetStderr sets the Command's standard error.
@@ -320,25 +1358,148 @@ func (c *Command) UsageError(msg string) error {
 	}
 }
 
+// UsageErrorWithHint is like UsageError
+// but the error also carries a hint
+// with a suggested fix,
+// for example "try: app hello --message world".
+// The hint is printed by Main
+// in its own line after the error.
+func (c *Command) UsageErrorWithHint(msg, hint string) error {
+	return usageError{
+		c:    c,
+		msg:  fmt.Sprintf("%s: %s", c.longName(), msg),
+		hint: hint,
+	}
+}
+
+// UsageHint returns the hint
+// of an usage error.
+// If err is not an usage error,
+// or the usage error does not have a hint,
+// it returns an empty string.
+func UsageHint(err error) string {
+	var ue usageError
+	if !errors.As(err, &ue) {
+		return ""
+	}
+	return ue.hint
+}
+
+// Available returns true if the Command
+// is available according to the feature gate
+// of the root Command.
+func (c *Command) available() bool {
+	r := c.root()
+	if r.FeatureGate == nil {
+		return true
+	}
+	if ok, cached := r.gates[c]; cached {
+		return ok
+	}
+	ok := r.FeatureGate(c)
+	if r.gates != nil {
+		r.gates[c] = ok
+	}
+	return ok
+}
+
+// Explained returns true
+// if the Command is executed by Explain,
+// and writes the formatted action
+// into the explanation.
+func (c *Command) explained(format string, a ...any) bool {
+	w := c.root().explain
+	if w == nil {
+		return false
+	}
+	fmt.Fprintf(w, "would "+format+"\n", a...)
+	return true
+}
+
+// Parent returns the parent of the Command,
+// or nil if the Command is a root Command.
+func (c *Command) Parent() *Command {
+	return c.parent
+}
+
+// Root returns the root Command
+// of the Command's tree.
+func (c *Command) Root() *Command {
+	return c.root()
+}
+
+// Runnable returns true
+// if the Command has a Run function.
+func (c *Command) Runnable() bool {
+	return c.Run != nil
+}
+
+// IsAvailableCommand returns true
+// if the Command can be used in a command line,
+// i.e. it is runnable or it has children,
+// and it is not hidden.
+// Help topics are not available commands.
+func (c *Command) IsAvailableCommand() bool {
+	if c.Hidden {
+		return false
+	}
+	return !c.isTopic()
+}
+
+// Walk calls fn for the Command
+// and each of its descendants,
+// in depth-first order.
+// Children are visited in lexicographic order.
+func (c *Command) Walk(fn func(*Command)) {
+	fn(c)
+	for _, n := range c.children() {
+		child, ok := c.child(n)
+		if !ok {
+			continue
+		}
+		child.Walk(fn)
+	}
+}
+
+// Capture executes the Command
+// and returns the output written
+// into the Command's standard output.
+func (c *Command) capture(args []string) ([]byte, error) {
+	defer func(stdout io.Writer) {
+		c.stdout = stdout
+	}(c.stdout)
+
+	var buf bytes.Buffer
+	c.stdout = &buf
+	err := c.Execute(args)
+	return buf.Bytes(), err
+}
+
 // Child returns a child Command
-// with the given name.
+// with the given name
+// or alias.
 func (c *Command) child(name string) (*Command, bool) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 
-	name = strings.ToLower(name)
+	name = c.fold(name)
 	if name == "" {
 		return nil, false
 	}
-	child, ok := c.commands[name]
-	return child, ok
+	if child, ok := c.commands[name]; ok {
+		return child, true
+	}
+	if canon, ok := c.aliases[name]; ok {
+		return c.commands[canon], true
+	}
+	return nil, false
 }
 
 // Children returns the names
 // of the children Commands.
 func (c *Command) children() []string {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 
 	var children []string
 	for _, c := range c.commands {
@@ -348,22 +1509,86 @@ func (c *Command) children() []string {
 	return children
 }
 
+// SaveFlags returns a function
+// that restores the flag sets of the Command,
+// and the flag data set by initFlags.
+func (c *Command) saveFlags() (restore func()) {
+	flags, pflags := c.flags, c.pflags
+	shorthands, inherited := c.shorthands, c.inherited
+	groups, together := c.flagGroups, c.requiredTogether
+	return func() {
+		c.flags, c.pflags = flags, pflags
+		c.shorthands, c.inherited = shorthands, inherited
+		c.flagGroups, c.requiredTogether = groups, together
+	}
+}
+
 // Help prints the help message of the Command.
 func (c *Command) help(args []string) error {
 	if len(args) == 0 {
-		help(c.Stdout(), c)
+		if c.explained("print help of %s", c.longName()) {
+			return nil
+		}
+		c.pagedHelp()
 		return nil
 	}
 
 	child, ok := c.child(args[0])
 	if !ok {
-		return fmt.Errorf("%s %s: unknown help topic. Run %q", c.helpPath(), strings.Join(args, " "), c.helpPath())
+		msg := fmt.Sprintf("%s %s: %s. Run %q", c.helpPath(), strings.Join(args, " "), c.messages().UnknownHelpTopic, c.helpPath())
+		if topics := c.helpTopics(); len(topics) > 0 {
+			msg += ". Valid topics: " + strings.Join(topics, ", ")
+		}
+		if dym := didYouMean(c.suggestions(args[0], true)); dym != "" {
+			msg += ". " + dym
+		}
+		return errors.New(msg)
+	}
+	if !child.available() {
+		return fmt.Errorf("%s: not available in your edition", child.longName())
 	}
 	return child.help(args[1:])
 }
 
+// HelpTopics returns the names
+// of the children commands and help topics
+// listed in the help of the Command.
+func (c *Command) helpTopics() []string {
+	var topics []string
+	for _, n := range c.children() {
+		child, ok := c.child(n)
+		if !ok || child.Hidden || child.Deprecated != "" || !child.available() {
+			continue
+		}
+		topics = append(topics, n)
+	}
+	return topics
+}
+
+// InitFlags initializes the flag set of the Command.
+func (c *Command) initFlags() {
+	c.flags = flag.NewFlagSet(c.name(), flag.ContinueOnError)
+	c.flags.SetOutput(io.Discard) // do not print flag errors
+	c.flags.Usage = func() {}
+	c.pflags = flag.NewFlagSet(c.name(), flag.ContinueOnError)
+	c.requiredTogether = nil
+	c.flagGroups = nil
+	c.shorthands = nil
+	if c.SetFlags != nil {
+		c.SetFlags(c)
+	}
+	c.addPersistentFlags()
+	if c.flags.Lookup(offlineFlag) == nil && c.root().requiresNetwork() {
+		c.flags.Bool(offlineFlag, false, "run without network access")
+	}
+	if c.parent == nil && c.Version != "" && c.flags.Lookup(versionFlagName) == nil {
+		c.flags.Var(new(versionFlag), versionFlagName, "print the application version")
+	}
+}
+
 // LongName returns the Command's long name,
 // i.e. the name of the Command and all of its parents.
+// Aliases are never used in the long name.
 func (c *Command) longName() string {
 	name := c.name()
 	for p := c.parent; p != nil; p = p.parent {
@@ -384,110 +1609,399 @@ func (c *Command) longUsage() string {
 
 // Name returns the Command's name.
 func (c *Command) name() string {
+	return c.fold(c.rawName())
+}
+
+// RawName returns the Command's name
+// as defined by the user,
+// i.e. the Name field,
+// or the first word of the usage.
+func (c *Command) rawName() string {
+	if c.Name != "" {
+		return c.Name
+	}
 	f := strings.Fields(c.Usage)
 	if len(f) == 0 {
 		return ""
 	}
-	return strings.ToLower(f[0])
+	return f[0]
+}
+
+// Fold normalizes a name
+// using the FoldFunc of the root Command.
+func (c *Command) fold(name string) string {
+	if f := c.root().FoldFunc; f != nil {
+		return f(name)
+	}
+	return strings.ToLower(name)
 }
 
 // HasChildren returns true if the command
 // has at least one child.
 func (c *Command) hasChildren() bool {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 
 	return len(c.commands) > 0
 }
 
+// IsTopic returns true
+// if the Command is a help topic,
+// i.e. it is not runnable
+// and it does not have children.
+func (c *Command) isTopic() bool {
+	return !c.Runnable() && !c.hasChildren()
+}
+
+// HelpName returns the name of the keyword
+// used to request the help.
+func (c *Command) helpName() string {
+	if name := c.root().HelpCommandName; name != "" {
+		return name
+	}
+	return "help"
+}
+
+// IsHelp returns true
+// if name is the keyword used to request the help.
+// It always returns false
+// if the automatic help is disabled.
+func (c *Command) isHelp(name string) bool {
+	if c.root().DisableAutoHelp {
+		return false
+	}
+	return c.fold(name) == c.fold(c.helpName())
+}
+
+// IsVersion returns true if name
+// is the version keyword of the Command.
+func (c *Command) isVersion(name string) bool {
+	return c.parent == nil && c.Version != "" && c.fold(name) == "version"
+}
+
 // HelpPath returns the help path of the Command.
+// It always uses the canonical names of the commands,
+// even if the Command was invoked with an alias.
 func (c *Command) helpPath() string {
 	var path []string
 	for p := c; p != nil; p = p.parent {
 		path = append([]string{p.name()}, path...)
 	}
-	path = append([]string{path[0], "help"}, path[1:]...)
+	path = append([]string{path[0], c.helpName()}, path[1:]...)
 	return strings.Join(path, " ")
 }
 
-// Usage prints the Command's usage.
-func (c *Command) usage(w io.Writer) {
-	if c.Run == nil {
-		return
+// Offline returns true if the --offline flag
+// is set in the Command
+// or any of its parents.
+func (c *Command) offline() bool {
+	for p := c; p != nil; p = p.parent {
+		if p.flags == nil {
+			continue
+		}
+		if f := p.flags.Lookup(offlineFlag); f != nil && f.Value.String() == "true" {
+			return true
+		}
 	}
-	fmt.Fprintf(w, "usage: %s\n", c.longUsage())
+	return false
 }
 
-type usageError struct {
-	c   *Command
-	msg string
+// RequiresNetwork returns true if the Command
+// or any of its descendants
+// requires network access.
+func (c *Command) requiresNetwork() bool {
+	if c.RequiresNetwork {
+		return true
+	}
+	for _, n := range c.children() {
+		child, ok := c.child(n)
+		if !ok {
+			continue
+		}
+		if child.requiresNetwork() {
+			return true
+		}
+	}
+	return false
 }
 
-func (e usageError) Error() string {
-	return e.msg
+// PersistentPreRun calls the PersistentPreRun functions
+// of the Command and its ancestors,
+// starting from the root.
+func (c *Command) persistentPreRun(args []string) error {
+	var chain []*Command
+	for p := c; p != nil; p = p.parent {
+		chain = append([]*Command{p}, chain...)
+	}
+	for _, p := range chain {
+		if p.PersistentPreRun == nil {
+			continue
+		}
+		if err := p.PersistentPreRun(c, args); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (e usageError) Is(target error) bool {
-	if _, ok := target.(usageError); ok {
-		return true
+// Warn prints a warning
+// in the Command's standard error,
+// unless the errors are silenced.
+func (c *Command) warn(format string, a ...any) {
+	if c.root().SilenceErrors {
+		return
 	}
-	return false
+	fmt.Fprintf(c.Stderr(), format+"\n", a...)
+}
+
+// PrintVersion prints the name and version
+// of the application.
+func (c *Command) printVersion() {
+	fmt.Fprintf(c.Stdout(), "%s %s\n", c.name(), c.Version)
 }
 
-// Help prints the help of a command on w.
-func help(w io.Writer, c *Command) {
-	fmt.Fprintf(w, "%s\n\n", toTitle(c.Short))
-	if c.Run != nil || c.hasChildren() {
-		fmt.Fprintf(w, "Usage:\n\n    %s\n\n", c.longUsage())
+// FlagsString returns a string representation
+// of the flags set in the command line.
+func (c *Command) flagsString() string {
+	var set []string
+	c.flags.Visit(func(f *flag.Flag) {
+		set = append(set, f.Name+":"+f.Value.String())
+	})
+	return "{" + strings.Join(set, " ") + "}"
+}
+
+// Path returns the names of the commands
+// from the root to the Command.
+func (c *Command) path() []string {
+	var path []string
+	for p := c; p != nil; p = p.parent {
+		path = append([]string{p.name()}, path...)
 	}
+	return path
+}
 
-	if long := strings.TrimSpace(c.Long); long != "" {
-		fmt.Fprintf(w, "%s\n\n", long)
+// Root returns the root of the Command's tree.
+func (c *Command) root() *Command {
+	r := c
+	for r.parent != nil {
+		r = r.parent
 	}
+	return r
+}
 
-	if !c.hasChildren() {
-		return
+// InputEncoding returns the encoding
+// of the standard input
+// as set in the Command or its ancestors.
+func (c *Command) inputEncoding() encoding.Encoding {
+	for p := c; p != nil; p = p.parent {
+		if p.inEnc != nil {
+			return p.inEnc
+		}
 	}
+	return nil
+}
 
-	children := c.children()
-	topics := false
-	fmt.Fprintf(w, "The commands are:\n\n")
-	for _, n := range children {
-		cmd, ok := c.child(n)
-		if !ok {
-			continue
+// OutputEncoding returns the encoding
+// of the standard output
+// as set in the Command or its ancestors.
+func (c *Command) outputEncoding() encoding.Encoding {
+	for p := c; p != nil; p = p.parent {
+		if p.outEnc != nil {
+			return p.outEnc
 		}
-		if cmd.Run == nil && !cmd.hasChildren() {
-			topics = true
-			continue
+	}
+	return nil
+}
+
+// Logger returns the logger
+// defined in the Command or its ancestors.
+func (c *Command) logger() *slog.Logger {
+	for p := c; p != nil; p = p.parent {
+		if p.Logger != nil {
+			return p.Logger
 		}
-		fmt.Fprintf(w, "    %-16s %s\n", cmd.name(), cmd.Short)
 	}
-	hp := c.helpPath()
-	fmt.Fprintf(w, "\nUse %q for more information about a command.\n\n", hp+" <command>")
+	return nil
+}
+
+// StartTrace starts the trace of a phase
+// and returns a function
+// to be called at the end of the phase.
+func (c *Command) startTrace(event string) func() {
+	tr := c.root().Trace
+	if tr == nil {
+		return noTrace
+	}
+	start := time.Now()
+	return func() {
+		tr(event, c, time.Since(start))
+	}
+}
+
+func noTrace() {}
+
+// Run runs the Command's Run function,
+// applying the middleware,
+// the encodings,
+// and the output filters.
+func (c *Command) run(args []string) (err error) {
+	run := c.Run
+	mw := c.root().middleware
+	for i := len(mw) - 1; i >= 0; i-- {
+		run = mw[i](run)
+	}
+
+	inEnc, outEnc := c.inputEncoding(), c.outputEncoding()
+	if c.OutputFilter == nil && c.OutputWrapper == nil && inEnc == nil && outEnc == nil {
+		return run(c, args)
+	}
+
+	defer func(stdin io.Reader, stdout io.Writer) {
+		c.stdin = stdin
+		c.stdout = stdout
+	}(c.stdin, c.stdout)
+
+	if inEnc != nil {
+		c.stdin = inEnc.NewDecoder().Reader(c.Stdin())
+	}
+
+	out := c.Stdout()
+	if outEnc != nil {
+		wc := transform.NewWriter(out, outEnc.NewEncoder())
+		defer func() {
+			if cErr := wc.Close(); err == nil {
+				err = cErr
+			}
+		}()
+		out = wc
+	}
+	if c.OutputWrapper != nil {
+		wc := c.OutputWrapper(c, out)
+		defer func() {
+			if cErr := wc.Close(); err == nil {
+				err = cErr
+			}
+		}()
+		out = wc
+	}
+	if c.OutputFilter == nil {
+		c.stdout = out
+		return run(c, args)
+	}
+
+	var buf bytes.Buffer
+	c.stdout = &buf
+	if err := run(c, args); err != nil {
+		out.Write(buf.Bytes())
+		return err
+	}
+	filtered, err := c.OutputFilter(c, buf.Bytes())
+	if err != nil {
+		return err
+	}
+	if _, err := out.Write(filtered); err != nil {
+		return err
+	}
+	return nil
+}
+
+// RunError formats an error
+// returned by the Command's Run function
+// or its hooks.
+// The original error is wrapped,
+// so it can be inspected with errors.Is and errors.As.
+func (c *Command) runError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, usageError{}) {
+		return err
+	}
+	if c.root().RawRunErrors {
+		return err
+	}
+	return fmt.Errorf("%s: %w", c.longName(), err)
+}
 
-	if !topics {
+// Usage prints the Command's usage.
+// Help topics do not have an usage.
+func (c *Command) usage(w io.Writer) {
+	if c.isTopic() {
 		return
 	}
-	fmt.Fprintf(w, "Additional help topics:\n\n")
-	for _, n := range children {
-		t, ok := c.child(n)
-		if !ok {
-			continue
-		}
-		if t.Run != nil || t.hasChildren() {
-			continue
+	for p := c; p != nil; p = p.parent {
+		if p.UsageFunc != nil {
+			fmt.Fprintf(w, "%s\n", p.UsageFunc(c))
+			return
 		}
-		fmt.Fprintf(w, "    %-16s %s\n", t.name(), t.Short)
 	}
-	fmt.Fprintf(w, "\nUse %q for more information about that topic.\n\n", hp+" <topic>")
+	fmt.Fprintf(w, c.messages().Usage+"\n", c.longUsage())
 }
 
-func toTitle(s string) string {
-	s = strings.Join(strings.Fields(s), " ")
-	if s == "" {
-		return ""
+// OfflineFlag is the name of the flag
+// used to indicate that there is no network access.
+const offlineFlag = "offline"
+
+// VersionFlagName is the name of the flag
+// used to print the application version.
+const versionFlagName = "version"
+
+// A versionFlag is the flag
+// used to print the application version.
+type versionFlag bool
+
+func (v *versionFlag) IsBoolFlag() bool { return true }
+func (v *versionFlag) String() string   { return strconv.FormatBool(bool(*v)) }
+
+func (v *versionFlag) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*v = versionFlag(b)
+	return nil
+}
+
+// HelpAllFlagName is the name of the flag
+// used to print the help of all commands.
+const helpAllFlagName = "help-all"
+
+// A helpAllFlag is the flag
+// used to print the help of all commands.
+type helpAllFlag bool
+
+func (h *helpAllFlag) IsBoolFlag() bool { return true }
+func (h *helpAllFlag) String() string   { return strconv.FormatBool(bool(*h)) }
+
+func (h *helpAllFlag) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*h = helpAllFlag(b)
+	return nil
+}
+
+type usageError struct {
+	c    *Command
+	msg  string
+	hint string
+
+	// wrapped error
+	err error
+}
+
+func (e usageError) Error() string {
+	return e.msg
+}
+
+func (e usageError) Unwrap() error {
+	return e.err
+}
+
+func (e usageError) Is(target error) bool {
+	if _, ok := target.(usageError); ok {
+		return true
 	}
-	r, i := utf8.DecodeRuneInString(s)
-	return string(unicode.ToTitle(r)) + s[i:]
+	return false
 }
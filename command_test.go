@@ -15,13 +15,17 @@ package command_test
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/js-arias/command"
+	"golang.org/x/text/encoding/charmap"
 )
 
 func TestCommand(t *testing.T) {
@@ -98,6 +102,28 @@ func TestCommand(t *testing.T) {
 			args: []string{"cmd", "cat", "-h"},
 			err:  "usage: app cmd cat",
 		},
+		"output filter": {
+			c: &command.Command{
+				Usage: "cat",
+				Run:   inToOutRun,
+				OutputFilter: func(c *command.Command, raw []byte) ([]byte, error) {
+					return bytes.ToUpper(raw), nil
+				},
+			},
+			in:  "input\nstring",
+			out: "INPUT\nSTRING",
+		},
+		"output wrapper": {
+			c: &command.Command{
+				Usage: "cat",
+				Run:   inToOutRun,
+				OutputWrapper: func(c *command.Command, w io.Writer) io.WriteCloser {
+					return upperWriter{w}
+				},
+			},
+			in:  "input\nstring",
+			out: "INPUT\nSTRING",
+		},
 	}
 
 	for name, test := range tests {
@@ -180,6 +206,57 @@ func TestError(t *testing.T) {
 			args:   []string{"cmd", "error"},
 			errMsg: "app cmd error: expecting arguments",
 		},
+		"raw error from a command": {
+			c: func() *command.Command {
+				app := newApp()
+				app.RawRunErrors = true
+				return app
+			}(),
+			args:   []string{"error"},
+			errMsg: "an error from a command",
+		},
+		"raw error (usage error)": {
+			c: func() *command.Command {
+				app := newApp()
+				app.RawRunErrors = true
+				return app
+			}(),
+			args:   []string{"cmd", "error"},
+			errMsg: "app cmd error: expecting arguments",
+		},
+		"unknown command with suggestion": {
+			c:      newApp(),
+			args:   []string{"helllo"},
+			errMsg: `app helllo: unknown command. Did you mean "hello"?`,
+		},
+		"unknown command with suggestion (caps)": {
+			c:      newApp(),
+			args:   []string{"CDM"},
+			errMsg: `app CDM: unknown command. Did you mean "cmd"?`,
+		},
+		"unknown command with multiple suggestions": {
+			c:      newApp(),
+			args:   []string{"helo"},
+			errMsg: `app helo: unknown command. Did you mean one of "hello", "help"?`,
+		},
+		"unknown command suggesting help": {
+			c:      newApp(),
+			args:   []string{"hlep"},
+			errMsg: `app hlep: unknown command. Did you mean "help"?`,
+		},
+		"unknown command suggesting from alias": {
+			c: func() *command.Command {
+				app := newApp()
+				app.Add(&command.Command{
+					Usage:   "remove <file>",
+					Aliases: []string{"rm", "del"},
+					Run:     echoToStderrRun,
+				})
+				return app
+			}(),
+			args:   []string{"rem"},
+			errMsg: `app rem: unknown command. Did you mean "remove"?`,
+		},
 		"undefined flag": {
 			c:      newApp(),
 			args:   []string{"hello", "--undef"},
@@ -233,6 +310,20 @@ func inToOutRun(c *command.Command, args []string) error {
 	return nil
 }
 
+// UpperWriter is a writer
+// that writes in upper case.
+type upperWriter struct {
+	w io.Writer
+}
+
+func (u upperWriter) Write(p []byte) (int, error) {
+	return u.w.Write(bytes.ToUpper(p))
+}
+
+func (u upperWriter) Close() error {
+	return nil
+}
+
 func cmdWithFlags() *command.Command {
 	var utf bool
 	var msg string
@@ -313,3 +404,816 @@ func newApp() *command.Command {
 
 	return app
 }
+
+func TestOffline(t *testing.T) {
+	newNetApp := func() *command.Command {
+		app := newApp()
+		app.Add(&command.Command{
+			Usage:           "fetch",
+			Short:           "fetch from network",
+			Run:             echoToStderrRun,
+			RequiresNetwork: true,
+		})
+		return app
+	}
+
+	testExecute(t, newNetApp(), []string{"fetch", "data"}, "", "", "data")
+	testExecute(t, newNetApp(), []string{"--offline", "hello"}, "", "hello, world", "")
+	testExecute(t, newNetApp(), []string{"cmd", "echo", "--offline", "local"}, "", "", "local")
+
+	msg := "app fetch: this command requires network access; remove --offline"
+	testExecuteError(t, newNetApp(), []string{"--offline", "fetch"}, msg)
+	testExecuteError(t, newNetApp(), []string{"fetch", "--offline"}, msg)
+
+	// --offline is only defined if a command requires network
+	testExecuteError(t, newApp(), []string{"--offline", "hello"}, "app: flag provided but not defined: -offline")
+}
+
+func TestFoldFunc(t *testing.T) {
+	app := &command.Command{
+		Usage: "app <command> [<argument>...]",
+		FoldFunc: func(s string) string {
+			return strings.ReplaceAll(strings.ToLower(s), "ß", "ss")
+		},
+	}
+	app.Add(&command.Command{
+		Usage: "straße <argument>...",
+		Short: "print its arguments",
+		Run:   echoToStderrRun,
+	})
+
+	testExecute(t, app, []string{"STRASSE", "street"}, "", "", "street")
+	testExecute(t, app, []string{"Straße", "street"}, "", "", "street")
+	testExecuteError(t, app, []string{"road"}, "app road: unknown command")
+}
+
+func TestPrefixMatch(t *testing.T) {
+	newPrefixApp := func() *command.Command {
+		app := newApp()
+		app.AllowPrefixMatch = true
+		return app
+	}
+
+	testExecute(t, newPrefixApp(), []string{"hel"}, "", "hello, world", "")
+	testExecute(t, newPrefixApp(), []string{"HEL"}, "", "hello, world", "")
+	testExecute(t, newPrefixApp(), []string{"c", "ec", "data"}, "", "", "data")
+	testExecuteError(t, newPrefixApp(), []string{"cmd", "e"}, `app cmd e: ambiguous command; could be one of "echo", "error"`)
+
+	// built-in keywords are not prefixes
+	app := newPrefixApp()
+	app.Version = "1.0"
+	app.Add(&command.Command{
+		Usage: "versions",
+		Short: "list the available versions",
+		Run: func(c *command.Command, args []string) error {
+			fmt.Fprintf(c.Stdout(), "1.0 0.9\n")
+			return nil
+		},
+	})
+	testExecute(t, app, []string{"version"}, "", "app 1.0", "")
+	testExecute(t, app, []string{"versi"}, "", "1.0 0.9", "")
+
+	// prefix matching is disabled by default
+	testExecuteError(t, newApp(), []string{"hel"}, `app hel: unknown command. Did you mean one of "help", "hello"?`)
+}
+
+func TestExecuteResult(t *testing.T) {
+	app := newApp()
+	app.Add(&command.Command{
+		Usage: "point",
+		Run: func(c *command.Command, args []string) error {
+			fmt.Fprintf(c.Stdout(), `{"x":1,"y":2}`)
+			return nil
+		},
+	})
+
+	type point struct {
+		X, Y int
+	}
+	decode := func(b []byte) (point, error) {
+		var p point
+		err := json.Unmarshal(b, &p)
+		return p, err
+	}
+
+	p, err := command.ExecuteResult(app, []string{"point"}, decode)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := (point{X: 1, Y: 2}); p != want {
+		t.Errorf("result: got %v, want %v", p, want)
+	}
+
+	_, err = command.ExecuteResult(app, []string{"error"}, decode)
+	if err == nil {
+		t.Fatalf("expecting error")
+	}
+	if msg := "app error: an error from a command"; err.Error() != msg {
+		t.Errorf("error: got %q, want %q", err, msg)
+	}
+}
+
+func TestRunHooks(t *testing.T) {
+	tests := map[string]struct {
+		preErr  error
+		runErr  error
+		postErr error
+		calls   string
+		errMsg  string
+	}{
+		"no errors": {
+			calls: "pre run post",
+		},
+		"error on pre-run": {
+			preErr: errors.New("pre-run error"),
+			calls:  "pre",
+			errMsg: "hook: pre-run error",
+		},
+		"error on run": {
+			runErr:  errors.New("run error"),
+			postErr: errors.New("post-run error"),
+			calls:   "pre run post",
+			errMsg:  "hook: run error",
+		},
+		"error on post-run": {
+			postErr: errors.New("post-run error"),
+			calls:   "pre run post",
+			errMsg:  "hook: post-run error",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var calls []string
+			c := &command.Command{
+				Usage: "hook",
+				PreRun: func(c *command.Command, args []string) error {
+					calls = append(calls, "pre")
+					return test.preErr
+				},
+				Run: func(c *command.Command, args []string) error {
+					calls = append(calls, "run")
+					return test.runErr
+				},
+				PostRun: func(c *command.Command, args []string) error {
+					calls = append(calls, "post")
+					return test.postErr
+				},
+			}
+
+			err := c.Execute(nil)
+			if got := strings.Join(calls, " "); got != test.calls {
+				t.Errorf("calls: got %q, want %q", got, test.calls)
+			}
+			var msg string
+			if err != nil {
+				msg = err.Error()
+			}
+			if msg != test.errMsg {
+				t.Errorf("error: got %q, want %q", msg, test.errMsg)
+			}
+		})
+	}
+}
+
+func TestPersistentPreRun(t *testing.T) {
+	var calls []string
+	hook := func(name string, err error) func(c *command.Command, args []string) error {
+		return func(c *command.Command, args []string) error {
+			calls = append(calls, name+":"+c.Usage)
+			return err
+		}
+	}
+
+	app := newApp()
+	app.PersistentPreRun = hook("app", nil)
+	cmd := &command.Command{
+		Usage:            "sub <command>",
+		PersistentPreRun: hook("sub", nil),
+	}
+	app.Add(cmd)
+	cmd.Add(&command.Command{
+		Usage:            "leaf",
+		PersistentPreRun: hook("leaf", nil),
+		PreRun:           hook("pre", nil),
+		Run:              hook("run", nil),
+	})
+	cmd.Add(&command.Command{
+		Usage:            "fail",
+		PersistentPreRun: hook("fail", errors.New("persistent error")),
+		Run:              hook("run", nil),
+	})
+
+	if err := app.Execute([]string{"sub", "leaf"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "app:leaf sub:leaf leaf:leaf pre:leaf run:leaf"
+	if got := strings.Join(calls, " "); got != want {
+		t.Errorf("calls: got %q, want %q", got, want)
+	}
+
+	calls = nil
+	testExecuteError(t, app, []string{"sub", "fail"}, "app sub fail: persistent error")
+	want = "app:fail sub:fail fail:fail"
+	if got := strings.Join(calls, " "); got != want {
+		t.Errorf("calls: got %q, want %q", got, want)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	app := newApp()
+	app.Version = "v1.2.3"
+
+	testExecute(t, app, []string{"--version"}, "", "app v1.2.3", "")
+	testExecute(t, app, []string{"version"}, "", "app v1.2.3", "")
+	testExecuteError(t, app, []string{"cmd", "version"}, "app cmd version: unknown command")
+
+	// user defined version command
+	app.Add(&command.Command{
+		Usage: "version",
+		Short: "print a custom version",
+		Run: func(c *command.Command, args []string) error {
+			fmt.Fprintf(c.Stdout(), "custom version\n")
+			return nil
+		},
+	})
+	testExecute(t, app, []string{"version"}, "", "custom version", "")
+
+	// no version
+	testExecuteError(t, newApp(), []string{"--version"}, "app: flag provided but not defined: -version")
+}
+
+func TestExplain(t *testing.T) {
+	tests := map[string]struct {
+		args []string
+		out  string
+	}{
+		"command with flags": {
+			args: []string{"hello", "--message", "explain", "--utf8", "arg1", "arg2"},
+			out:  "would run: app hello with flags {message:explain utf8:true} and args [arg1 arg2]",
+		},
+		"nested command": {
+			args: []string{"cmd", "cat"},
+			out:  "would run: app cmd cat with flags {} and args []",
+		},
+		"help": {
+			args: []string{"help", "cmd"},
+			out:  "would print help of app cmd",
+		},
+		"help flag": {
+			args: []string{"cmd", "-h"},
+			out:  "would print help of app cmd",
+		},
+		"help flag of a leaf": {
+			args: []string{"hello", "-h"},
+			out:  "would print usage of app hello",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			app := newApp()
+			var outBuf, errBuf bytes.Buffer
+			app.SetStdout(&outBuf)
+			app.SetStderr(&errBuf)
+
+			var b strings.Builder
+			if err := app.Explain(test.args, &b); err != nil {
+				t.Fatalf("args %v: unexpected error: %v", test.args, err)
+			}
+			if got := strings.TrimSpace(b.String()); got != test.out {
+				t.Errorf("args %v: got %q, want %q", test.args, got, test.out)
+			}
+			if outBuf.Len() > 0 || errBuf.Len() > 0 {
+				t.Errorf("args %v: unexpected output: %q %q", test.args, outBuf.String(), errBuf.String())
+			}
+		})
+	}
+}
+
+func TestAuditLogger(t *testing.T) {
+	tests := map[string]struct {
+		args []string
+		path string
+		rest string
+		err  string
+	}{
+		"command": {
+			args: []string{"hello", "--message", "audit"},
+			path: "app hello",
+			rest: "--message audit",
+		},
+		"grand children": {
+			args: []string{"cmd", "echo", "one", "two"},
+			path: "app cmd echo",
+			rest: "one two",
+		},
+		"error": {
+			args: []string{"error", "arg"},
+			path: "app error",
+			rest: "arg",
+			err:  "app error: an error from a command",
+		},
+		"unknown command": {
+			args: []string{"cmd", "unknown"},
+			path: "app cmd",
+			rest: "unknown",
+			err:  "app cmd unknown: unknown command",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			app := newApp()
+			app.SetStderr(io.Discard)
+			calls := 0
+			app.SetAuditLogger(func(path, args []string, err error) {
+				calls++
+				if got := strings.Join(path, " "); got != test.path {
+					t.Errorf("path: got %q, want %q", got, test.path)
+				}
+				if got := strings.Join(args, " "); got != test.rest {
+					t.Errorf("args: got %q, want %q", got, test.rest)
+				}
+				var msg string
+				if err != nil {
+					msg = err.Error()
+				}
+				if msg != test.err {
+					t.Errorf("error: got %q, want %q", msg, test.err)
+				}
+			})
+			app.Execute(test.args)
+			if calls != 1 {
+				t.Errorf("audit logger called %d times, want 1", calls)
+			}
+		})
+	}
+}
+
+func TestAuditLoggerNested(t *testing.T) {
+	app := newApp()
+	app.Add(&command.Command{
+		Usage: "wrap",
+		Short: "run hello",
+		Run: func(c *command.Command, args []string) error {
+			return c.Root().Execute([]string{"hello", "--message", "wrapped"})
+		},
+	})
+	var paths []string
+	app.SetAuditLogger(func(path, args []string, err error) {
+		paths = append(paths, strings.Join(path, " "))
+	})
+
+	testExecute(t, app, []string{"wrap"}, "", "hello, wrapped", "")
+	if got, want := strings.Join(paths, ", "), "app hello, app wrap"; got != want {
+		t.Errorf("paths: got %q, want %q", got, want)
+	}
+
+	paths = nil
+	if err := app.Explain([]string{"hello"}, io.Discard); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(paths) > 0 {
+		t.Errorf("explain: audit logger called with %q", paths)
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	app := newApp()
+	app.SetInputEncoding(charmap.ISO8859_1)
+	testExecute(t, app, []string{"cmd", "cat"}, "caf\xe9\n", "café", "")
+
+	app = newApp()
+	app.SetOutputEncoding(charmap.ISO8859_1)
+	testExecute(t, app, []string{"cmd", "cat"}, "café\n", "caf\xe9", "")
+
+	app = newApp()
+	app.SetInputEncoding(charmap.ISO8859_1)
+	app.SetOutputEncoding(charmap.ISO8859_1)
+	testExecute(t, app, []string{"cmd", "cat"}, "caf\xe9\n", "caf\xe9", "")
+
+	// without an encoding bytes are unchanged
+	testExecute(t, newApp(), []string{"cmd", "cat"}, "caf\xe9\n", "caf\xe9", "")
+}
+
+func TestUsageHint(t *testing.T) {
+	c := &command.Command{
+		Usage: "greet <name>",
+		Run: func(c *command.Command, args []string) error {
+			if len(args) == 0 {
+				return c.UsageErrorWithHint("expecting a name", "try: greet world")
+			}
+			return c.UsageError("unexpected name")
+		},
+	}
+
+	testExecuteError(t, c, nil, "greet: expecting a name")
+	if hint := command.UsageHint(c.Execute(nil)); hint != "try: greet world" {
+		t.Errorf("hint: got %q, want %q", hint, "try: greet world")
+	}
+	if hint := command.UsageHint(c.Execute([]string{"john"})); hint != "" {
+		t.Errorf("hint: got %q, want an empty hint", hint)
+	}
+	if hint := command.UsageHint(errors.New("an error")); hint != "" {
+		t.Errorf("hint: got %q, want an empty hint", hint)
+	}
+}
+
+var errSentinel = errors.New("sentinel error")
+
+func TestErrorChain(t *testing.T) {
+	app := newApp()
+	app.Add(&command.Command{
+		Usage: "fail",
+		Run: func(c *command.Command, args []string) error {
+			return fmt.Errorf("failing: %w", errSentinel)
+		},
+	})
+
+	err := app.Execute([]string{"fail"})
+	if !errors.Is(err, errSentinel) {
+		t.Errorf("error %v: expecting the sentinel error in the chain", err)
+	}
+	if got, want := err.Error(), "app fail: failing: sentinel error"; got != want {
+		t.Errorf("error: got %q, want %q", got, want)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	app := newApp()
+	hello := &command.Command{
+		Usage:   "greet",
+		Aliases: []string{"hi"},
+		Run: func(c *command.Command, args []string) error {
+			fmt.Fprintf(c.Stdout(), "greetings\n")
+			return nil
+		},
+	}
+	app.Add(hello)
+	testExecute(t, app, []string{"hi"}, "", "greetings", "")
+
+	app.Remove(hello)
+	testExecuteError(t, app, []string{"greet"}, "app greet: unknown command")
+	testExecuteError(t, app, []string{"hi"}, "app hi: unknown command")
+
+	// a removed command can be added again
+	other := &command.Command{Usage: "other"}
+	other.Add(hello)
+	testExecute(t, other, []string{"greet"}, "", "greetings", "")
+}
+
+func TestConcurrentAdd(t *testing.T) {
+	app := newApp()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			c := &command.Command{
+				Usage: fmt.Sprintf("cmd%d", i),
+				Run:   echoToStderrRun,
+			}
+			app.Add(c)
+			if i%2 == 0 {
+				app.Remove(c)
+			}
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		var out bytes.Buffer
+		app.SetStdout(&out)
+		app.SetStderr(io.Discard)
+		if err := app.Execute([]string{"hello"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		app.Execute([]string{"help"})
+	}
+	<-done
+}
+
+func TestDefault(t *testing.T) {
+	app := newApp()
+	app.Default = "hello"
+	testExecute(t, app, nil, "", "hello, world", "")
+	testExecute(t, app, []string{"cmd", "echo", "data"}, "", "", "data")
+
+	app = newApp()
+	app.Default = "status"
+	testExecuteError(t, app, nil, `app: default command "status" not found`)
+}
+
+func TestRunnableParent(t *testing.T) {
+	newParentApp := func() *command.Command {
+		app := newApp()
+		parent := &command.Command{
+			Usage: "parent [<argument>...]",
+			Short: "a runnable parent",
+			Run:   echoToStderrRun,
+		}
+		parent.Add(&command.Command{
+			Usage: "child <argument>...",
+			Short: "a child",
+			Run: func(c *command.Command, args []string) error {
+				fmt.Fprintf(c.Stdout(), "child: %s\n", strings.Join(args, " "))
+				return nil
+			},
+		})
+		app.Add(parent)
+		return app
+	}
+
+	testExecute(t, newParentApp(), []string{"parent"}, "", "", "")
+	testExecute(t, newParentApp(), []string{"parent", "child", "a"}, "", "child: a", "")
+	testExecute(t, newParentApp(), []string{"parent", "notachild", "a"}, "", "", "notachild a")
+	testExecute(t, newParentApp(), []string{"parent", "--", "child"}, "", "", "child")
+}
+
+func TestRawArgs(t *testing.T) {
+	app := newApp()
+	app.Add(&command.Command{
+		Usage: "raw [--verbose] <argument>...",
+		Run: func(c *command.Command, args []string) error {
+			raw := c.RawArgs()
+			fmt.Fprintf(c.Stdout(), "%s\n", strings.Join(raw, " "))
+			raw[0] = "modified"
+			fmt.Fprintf(c.Stdout(), "%s\n", strings.Join(c.RawArgs(), " "))
+			return nil
+		},
+		SetFlags: func(c *command.Command) {
+			c.Flags().Bool("verbose", false, "")
+		},
+	})
+
+	testExecute(t, app, []string{"raw", "--verbose", "a", "b"}, "", "--verbose a b\n--verbose a b", "")
+}
+
+func TestTrace(t *testing.T) {
+	app := newApp()
+	var events []string
+	app.Trace = func(event string, c *command.Command, d time.Duration) {
+		if d < 0 {
+			t.Errorf("event %q: negative duration %v", event, d)
+		}
+		events = append(events, c.Usage[:strings.Index(c.Usage+" ", " ")]+":"+event)
+	}
+	app.Add(&command.Command{
+		Usage:   "hooks",
+		Run:     func(c *command.Command, args []string) error { return nil },
+		PostRun: func(c *command.Command, args []string) error { return nil },
+	})
+
+	testExecute(t, app, []string{"hello"}, "", "hello, world", "")
+	want := "app:parse hello:parse hello:prerun hello:run"
+	if got := strings.Join(events, " "); got != want {
+		t.Errorf("events: got %q, want %q", got, want)
+	}
+
+	events = nil
+	testExecute(t, app, []string{"hooks"}, "", "", "")
+	want = "app:parse hooks:parse hooks:prerun hooks:run hooks:postrun"
+	if got := strings.Join(events, " "); got != want {
+		t.Errorf("events: got %q, want %q", got, want)
+	}
+}
+
+func TestIsAvailableCommand(t *testing.T) {
+	app := newApp()
+	app.Add(&command.Command{
+		Usage:  "secret",
+		Run:    echoToStderrRun,
+		Hidden: true,
+	})
+
+	tests := map[string]struct {
+		runnable  bool
+		available bool
+	}{
+		"app":    {runnable: false, available: true},
+		"cmd":    {runnable: false, available: true},
+		"echo":   {runnable: true, available: true},
+		"topic":  {runnable: false, available: false},
+		"secret": {runnable: true, available: false},
+	}
+
+	app.Walk(func(c *command.Command) {
+		name := strings.Fields(c.Usage)[0]
+		test, ok := tests[name]
+		if !ok {
+			return
+		}
+		if got := c.Runnable(); got != test.runnable {
+			t.Errorf("%s: runnable %v, want %v", name, got, test.runnable)
+		}
+		if got := c.IsAvailableCommand(); got != test.available {
+			t.Errorf("%s: available %v, want %v", name, got, test.available)
+		}
+	})
+}
+
+func TestName(t *testing.T) {
+	app := newApp()
+	app.Add(&command.Command{
+		Name:  "ls",
+		Usage: "list [<dir>...]",
+		Short: "list directories",
+		Run: func(c *command.Command, args []string) error {
+			if len(args) == 0 {
+				return c.UsageError("expecting directory")
+			}
+			return echoToStderrRun(c, args)
+		},
+	})
+
+	testExecute(t, app, []string{"ls", "dir"}, "", "", "dir")
+	testExecute(t, app, []string{"ls", "-h"}, "", "", "usage: app list [<dir>...]")
+	testExecuteError(t, app, []string{"ls"}, "app ls: expecting directory")
+	testExecuteError(t, app, []string{"list"}, `app list: unknown command. Did you mean "ls"?`)
+}
+
+func TestLogger(t *testing.T) {
+	var b strings.Builder
+	app := newApp()
+	app.Logger = slog.New(slog.NewTextHandler(&b, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	testExecute(t, app, []string{"cmd", "echo", "hello"}, "", "", "hello")
+	want := `level=DEBUG msg=execute command=app args="[cmd echo hello]"
+level=DEBUG msg="flags parsed" command=app flags={} args="[cmd echo hello]"
+level=DEBUG msg="flags parsed" command="app cmd" flags={} args="[echo hello]"
+level=DEBUG msg="flags parsed" command="app cmd echo" flags={} args=[hello]
+level=DEBUG msg="execution finished" command="app cmd echo"
+`
+	if got := b.String(); got != want {
+		t.Errorf("log: got\n%s\nwant\n%s", got, want)
+	}
+
+	b.Reset()
+	app.Execute([]string{"error"})
+	want = `level=ERROR msg="execution failed" command="app error" error="app error: an error from a command"`
+	if got := b.String(); !strings.Contains(got, want) {
+		t.Errorf("log: expecting %q in\n%s", want, got)
+	}
+}
+
+func TestSetIO(t *testing.T) {
+	app := newApp()
+	in := strings.NewReader("input\n")
+	var out, errOut bytes.Buffer
+	app.SetIO(in, &out, &errOut)
+
+	app.Walk(func(c *command.Command) {
+		gotIn, gotOut, gotErr := c.IO()
+		if gotIn != in || gotOut != &out || gotErr != &errOut {
+			t.Errorf("%s: IO: streams are not inherited from the root", c.Usage)
+		}
+	})
+
+	if err := app.Execute([]string{"cmd", "cat"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := out.String(); got != "input\n" {
+		t.Errorf("stdout: got %q, want %q", got, "input\n")
+	}
+}
+
+func TestParentRoot(t *testing.T) {
+	app := newApp()
+	app.Walk(func(c *command.Command) {
+		if c.Root() != app {
+			t.Errorf("%s: root: got %q, want %q", c.Usage, c.Root().Usage, app.Usage)
+		}
+		if c == app {
+			if c.Parent() != nil {
+				t.Errorf("%s: parent: got %q, want nil", c.Usage, c.Parent().Usage)
+			}
+			return
+		}
+		found := false
+		c.Parent().Walk(func(d *command.Command) {
+			if d == c {
+				found = true
+			}
+		})
+		if !found {
+			t.Errorf("%s: parent %q does not contain the command", c.Usage, c.Parent().Usage)
+		}
+	})
+
+	// reading a value from the parent
+	var verbose bool
+	parent := &command.Command{
+		Usage: "parent <command>",
+		SetFlags: func(c *command.Command) {
+			c.PersistentFlags().BoolVar(&verbose, "v", false, "")
+		},
+	}
+	parent.Add(&command.Command{
+		Usage: "child",
+		Run: func(c *command.Command, args []string) error {
+			v, _ := c.Parent().FlagValue("v")
+			fmt.Fprintf(c.Stdout(), "%s\n", v)
+			return nil
+		},
+	})
+	testExecute(t, parent, []string{"-v", "child"}, "", "true", "")
+}
+
+func TestValue(t *testing.T) {
+	type configKey struct{}
+
+	app := newApp()
+	app.PersistentPreRun = func(c *command.Command, args []string) error {
+		c.Root().SetValue(configKey{}, "config from root")
+		return nil
+	}
+	app.Add(&command.Command{
+		Usage: "show",
+		Run: func(c *command.Command, args []string) error {
+			v, ok := c.Value(configKey{})
+			fmt.Fprintf(c.Stdout(), "%v %v\n", v, ok)
+			if _, ok := c.Value("undefined"); ok {
+				return errors.New("unexpected value")
+			}
+			return nil
+		},
+	})
+	testExecute(t, app, []string{"show"}, "", "config from root true", "")
+
+	// a child shadows the value of the root
+	app.PersistentPreRun = func(c *command.Command, args []string) error {
+		c.Root().SetValue(configKey{}, "config from root")
+		c.SetValue(configKey{}, "config from child")
+		return nil
+	}
+	testExecute(t, app, []string{"show"}, "", "config from child true", "")
+}
+
+func TestUse(t *testing.T) {
+	app := newApp()
+	var b strings.Builder
+	for _, name := range []string{"first", "second"} {
+		name := name
+		app.Use(func(next command.RunFunc) command.RunFunc {
+			return func(c *command.Command, args []string) error {
+				fmt.Fprintf(&b, "%s before %s\n", name, c.Usage)
+				err := next(c, args)
+				fmt.Fprintf(&b, "%s after: %v\n", name, err)
+				return err
+			}
+		})
+	}
+	app.Use(func(next command.RunFunc) command.RunFunc {
+		return func(c *command.Command, args []string) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("panic: %v", r)
+				}
+			}()
+			return next(c, args)
+		}
+	})
+	app.Add(&command.Command{
+		Usage: "panic",
+		Run: func(c *command.Command, args []string) error {
+			panic("boom")
+		},
+	})
+
+	testExecute(t, app, []string{"cmd", "echo", "hello"}, "", "", "hello")
+	want := `first before echo <argument>...
+second before echo <argument>...
+second after: <nil>
+first after: <nil>
+`
+	if got := b.String(); got != want {
+		t.Errorf("middleware: got\n%s\nwant\n%s", got, want)
+	}
+
+	b.Reset()
+	testExecuteError(t, app, []string{"panic"}, "app panic: panic: boom")
+}
+
+func TestUnknownCommandFunc(t *testing.T) {
+	app := newApp()
+	app.UnknownCommandFunc = func(c *command.Command, name string, args []string) error {
+		fmt.Fprintf(c.Stdout(), "app-%s %s", name, strings.Join(args, " "))
+		return nil
+	}
+
+	testExecute(t, app, []string{"plugin", "--flag", "x"}, "", "app-plugin --flag x", "")
+	testExecute(t, app, []string{"hello"}, "", "hello, world", "")
+
+	err := app.Execute([]string{"cmd", "unknown"})
+	if !errors.Is(err, command.ErrUnknownCommand) {
+		t.Errorf("error %v: expecting ErrUnknownCommand", err)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+// Package commandtest implements utilities
+// for testing commands.
+package commandtest
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/js-arias/command"
+)
+
+// Run executes a Command
+// with the given arguments,
+// using stdin as the standard input,
+// and returns the text written
+// in the standard output and error,
+// without leading and trailing spaces,
+// and the error returned by Execute.
+//
+// The standard input, output, and error
+// of the Command are replaced.
+func Run(c *command.Command, args []string, stdin string) (stdout, stderr string, err error) {
+	c.SetStdin(strings.NewReader(stdin))
+	var outBuf, errBuf bytes.Buffer
+	c.SetStdout(&outBuf)
+	c.SetStderr(&errBuf)
+
+	err = c.Execute(args)
+	return strings.TrimSpace(outBuf.String()), strings.TrimSpace(errBuf.String()), err
+}
+
+// VerifyExamples runs the examples
+// of a Command and all of its descendants,
+// and reports an error in t
+// if the output of an example
+// is different from the expected output.
+// Only the trailing newlines
+// of the output are ignored.
+// The Command must be the root Command,
+// as the examples command line
+// start with the application name.
+//
+// The standard input, output, and error
+// of the Command are replaced.
+func VerifyExamples(t testing.TB, c *command.Command) {
+	t.Helper()
+
+	c.Walk(func(cmd *command.Command) {
+		for _, e := range cmd.ExampleCases() {
+			args, err := e.Args()
+			if err != nil {
+				t.Errorf("%v", err)
+				continue
+			}
+			c.SetStdin(strings.NewReader(""))
+			var outBuf bytes.Buffer
+			c.SetStdout(&outBuf)
+			c.SetStderr(io.Discard)
+			err = c.Execute(args)
+			if err != nil {
+				t.Errorf("example %q: unexpected error: %v", e.Command, err)
+				continue
+			}
+			out := strings.TrimRight(outBuf.String(), "\n")
+			if out != e.Output {
+				t.Errorf("example %q: got output %q, want %q", e.Command, out, e.Output)
+			}
+		}
+	})
+}
@@ -0,0 +1,129 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package commandtest_test
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/js-arias/command"
+	"github.com/js-arias/command/commandtest"
+)
+
+func TestRun(t *testing.T) {
+	c := &command.Command{
+		Usage: "upper <argument>...",
+		Run: func(c *command.Command, args []string) error {
+			in, err := io.ReadAll(c.Stdin())
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(c.Stdout(), "%s\n", strings.ToUpper(string(in)))
+			fmt.Fprintf(c.Stderr(), "  %s  \n", strings.Join(args, " "))
+			if len(args) == 0 {
+				return c.UsageError("expecting arguments")
+			}
+			return nil
+		},
+	}
+
+	out, errOut, err := commandtest.Run(c, []string{"a", "b"}, "input")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != "INPUT" {
+		t.Errorf("stdout: got %q, want %q", out, "INPUT")
+	}
+	if errOut != "a b" {
+		t.Errorf("stderr: got %q, want %q", errOut, "a b")
+	}
+
+	_, _, err = commandtest.Run(c, nil, "")
+	if err == nil || err.Error() != "upper: expecting arguments" {
+		t.Errorf("error: got %v, want %q", err, "upper: expecting arguments")
+	}
+}
+
+func TestVerifyExamples(t *testing.T) {
+	app := &command.Command{
+		Usage: "app <command> [<argument>...]",
+	}
+	app.Add(&command.Command{
+		Usage: "say <argument>...",
+		Run: func(c *command.Command, args []string) error {
+			fmt.Fprintf(c.Stdout(), "%s\n", strings.Join(args, "\n"))
+			return nil
+		},
+		Examples: `
+Print a message:
+
+	$ app say hello
+	hello
+
+	$ app say "hello world" 'good bye'
+	hello world
+	good bye
+
+	$ app say "  indented"
+	  indented
+`,
+	})
+
+	commandtest.VerifyExamples(t, app)
+}
+
+// errorRecorder is a testing.TB
+// that records the reported errors.
+type errorRecorder struct {
+	testing.TB
+	errs []string
+}
+
+func (r *errorRecorder) Errorf(format string, args ...any) {
+	r.errs = append(r.errs, fmt.Sprintf(format, args...))
+}
+
+func (r *errorRecorder) Helper() {}
+
+func TestVerifyExamplesMismatch(t *testing.T) {
+	app := &command.Command{
+		Usage: "app <command> [<argument>...]",
+	}
+	app.Add(&command.Command{
+		Usage: "say <argument>...",
+		Run: func(c *command.Command, args []string) error {
+			fmt.Fprintf(c.Stdout(), "%s\n", strings.Join(args, "\n"))
+			return nil
+		},
+		Examples: `
+	$ app say hello
+	hello
+
+	$ app say bye
+	hello
+
+	$ app say "  spaces"
+	spaces
+`,
+	})
+
+	r := &errorRecorder{TB: t}
+	commandtest.VerifyExamples(r, app)
+
+	want := []string{
+		`example "app say bye": got output "bye", want "hello"`,
+		`example "app say \"  spaces\"": got output "  spaces", want "spaces"`,
+	}
+	if len(r.errs) != len(want) {
+		t.Fatalf("errors: got %q, want %q", r.errs, want)
+	}
+	for i, w := range want {
+		if r.errs[i] != w {
+			t.Errorf("error %d: got %q, want %q", i, r.errs[i], w)
+		}
+	}
+}
@@ -0,0 +1,651 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+//
+// This work is derived from:
+//     * Cobra source code
+//       available at: https://github.com/spf13/cobra.
+//       Copyright 2013 Steve Francia.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// GenBashCompletion writes a bash completion script
+// for the Command and all of its descendants
+// into w.
+//
+// At each level,
+// the script completes the names of the children commands,
+// or the flags of the command
+// if the word to complete starts with a dash.
+//
+// The script is keyed by the Command's name,
+// so it should be called on the root Command.
+//
+// If DynamicCompletion is set,
+// the script calls the application
+// to complete every word.
+func (c *Command) GenBashCompletion(w io.Writer) error {
+	if c.root().DynamicCompletion {
+		return c.genBashDynamic(w)
+	}
+
+	var b strings.Builder
+	name := c.name()
+	fn := "_" + shellName(name)
+
+	fmt.Fprintf(&b, "# bash completion for %s\n\n", name)
+	fmt.Fprintf(&b, "%s() {\n", fn)
+	fmt.Fprintf(&b, "\tlocal cur cmd word i help\n")
+	fmt.Fprintf(&b, "\tcur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	fmt.Fprintf(&b, "\tcmd=%q\n", c.longName())
+
+	// build the command path
+	var paths []string
+	c.Walk(func(cmd *Command) {
+		if cmd == c {
+			return
+		}
+		if !cmd.completable() {
+			return
+		}
+		paths = append(paths, fmt.Sprintf("%q", cmd.longName()))
+	})
+	autoHelp := !c.root().DisableAutoHelp
+	if len(paths) > 0 {
+		fmt.Fprintf(&b, "\tfor ((i = 1; i < COMP_CWORD; i++)); do\n")
+		fmt.Fprintf(&b, "\t\tword=\"${COMP_WORDS[i],,}\"\n")
+		if autoHelp {
+			// the help keyword completes the same commands
+			fmt.Fprintf(&b, "\t\tif [[ -z \"${help}\" && \"${word}\" == %q ]]; then\n", c.fold(c.helpName()))
+			fmt.Fprintf(&b, "\t\t\thelp=1\n")
+			fmt.Fprintf(&b, "\t\t\tcontinue\n")
+			fmt.Fprintf(&b, "\t\tfi\n")
+		}
+		fmt.Fprintf(&b, "\t\tcase \"${cmd} ${word}\" in\n")
+		fmt.Fprintf(&b, "\t\t%s)\n", strings.Join(paths, "|"))
+		fmt.Fprintf(&b, "\t\t\tcmd=\"${cmd} ${word}\"\n")
+		fmt.Fprintf(&b, "\t\t\t;;\n")
+		fmt.Fprintf(&b, "\t\tesac\n")
+		fmt.Fprintf(&b, "\tdone\n")
+	}
+	fmt.Fprintf(&b, "\n")
+
+	// commands and flags at each level
+	fmt.Fprintf(&b, "\tlocal commands flags\n")
+	fmt.Fprintf(&b, "\tcase \"${cmd}\" in\n")
+	c.Walk(func(cmd *Command) {
+		if cmd != c && !cmd.completable() {
+			return
+		}
+		fmt.Fprintf(&b, "\t%q)\n", cmd.longName())
+		fmt.Fprintf(&b, "\t\tcommands=%q\n", strings.Join(cmd.completableChildren(), " "))
+		fs, done := cmd.completionFlags()
+		fmt.Fprintf(&b, "\t\tflags=%q\n", strings.Join(flagNames(fs), " "))
+		done()
+		fmt.Fprintf(&b, "\t\t;;\n")
+	})
+	fmt.Fprintf(&b, "\tesac\n\n")
+
+	if autoHelp && len(paths) > 0 {
+		fmt.Fprintf(&b, "\tif [[ -n \"${help}\" ]]; then\n")
+		fmt.Fprintf(&b, "\t\tCOMPREPLY=($(compgen -W \"${commands}\" -- \"${cur}\"))\n")
+		fmt.Fprintf(&b, "\t\treturn\n")
+		fmt.Fprintf(&b, "\tfi\n")
+	}
+
+	fmt.Fprintf(&b, "\tif [[ \"${cur}\" == -* ]]; then\n")
+	fmt.Fprintf(&b, "\t\tCOMPREPLY=($(compgen -W \"${flags}\" -- \"${cur}\"))\n")
+	fmt.Fprintf(&b, "\t\treturn\n")
+	fmt.Fprintf(&b, "\tfi\n")
+	fmt.Fprintf(&b, "\tCOMPREPLY=($(compgen -W \"${commands}\" -- \"${cur}\"))\n")
+	fmt.Fprintf(&b, "}\n\n")
+	fmt.Fprintf(&b, "complete -F %s %s\n", fn, name)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// GenZshCompletion writes a zsh completion script
+// for the Command and all of its descendants
+// into w.
+//
+// The script completes the names of the children commands
+// using their short description,
+// and the flags of each command
+// using the flag usage as description.
+//
+// The script is keyed by the Command's name,
+// so it should be called on the root Command.
+//
+// If DynamicCompletion is set,
+// the script calls the application
+// to complete every word.
+func (c *Command) GenZshCompletion(w io.Writer) error {
+	if c.root().DynamicCompletion {
+		return c.genZshDynamic(w)
+	}
+
+	var b strings.Builder
+	name := c.name()
+	autoHelp := !c.root().DisableAutoHelp
+	fmt.Fprintf(&b, "#compdef %s\n", name)
+
+	c.Walk(func(cmd *Command) {
+		if cmd != c && !cmd.completable() {
+			return
+		}
+		fmt.Fprintf(&b, "\n%s() {\n", zshFuncName(cmd))
+		fs, done := cmd.completionFlags()
+		flags := zshFlags(fs)
+		done()
+		children := cmd.completableChildren()
+		if len(children) == 0 {
+			fmt.Fprintf(&b, "\t_arguments")
+			for _, f := range flags {
+				fmt.Fprintf(&b, " \\\n\t\t%s", f)
+			}
+			for _, a := range zshArgs(cmd) {
+				fmt.Fprintf(&b, " \\\n\t\t%s", a)
+			}
+			fmt.Fprintf(&b, "\n}\n")
+			return
+		}
+
+		fmt.Fprintf(&b, "\tlocal curcontext=\"$curcontext\" state line\n")
+		fmt.Fprintf(&b, "\tlocal -a commands\n")
+		fmt.Fprintf(&b, "\tcommands=(\n")
+		for _, n := range children {
+			child, _ := cmd.child(n)
+			desc := strings.ReplaceAll(n, ":", "\\:") + ":" + strings.Join(strings.Fields(child.Short), " ")
+			fmt.Fprintf(&b, "\t\t%s\n", zshQuote(desc))
+		}
+		fmt.Fprintf(&b, "\t)\n\n")
+
+		fmt.Fprintf(&b, "\t_arguments -C")
+		for _, f := range flags {
+			fmt.Fprintf(&b, " \\\n\t\t%s", f)
+		}
+		fmt.Fprintf(&b, " \\\n\t\t'1: :->command' \\\n\t\t'*:: :->argument'\n\n")
+
+		fmt.Fprintf(&b, "\tcase $state in\n")
+		fmt.Fprintf(&b, "\tcommand)\n")
+		fmt.Fprintf(&b, "\t\t_describe -t commands %s commands\n", zshQuote(cmd.longName()+" commands"))
+		fmt.Fprintf(&b, "\t\t;;\n")
+		fmt.Fprintf(&b, "\targument)\n")
+		fmt.Fprintf(&b, "\t\tcase ${words[1]:l} in\n")
+		for _, n := range children {
+			child, _ := cmd.child(n)
+			fmt.Fprintf(&b, "\t\t%s)\n", zshQuote(n))
+			fmt.Fprintf(&b, "\t\t\t%s\n", zshFuncName(child))
+			fmt.Fprintf(&b, "\t\t\t;;\n")
+		}
+		if autoHelp {
+			fmt.Fprintf(&b, "\t\t%s)\n", zshQuote(c.fold(c.helpName())))
+			fmt.Fprintf(&b, "\t\t\t%s\n", zshHelpFuncName(cmd))
+			fmt.Fprintf(&b, "\t\t\t;;\n")
+		}
+		fmt.Fprintf(&b, "\t\tesac\n")
+		fmt.Fprintf(&b, "\t\t;;\n")
+		fmt.Fprintf(&b, "\tesac\n")
+		fmt.Fprintf(&b, "}\n")
+
+		if autoHelp {
+			zshHelp(&b, cmd)
+		}
+	})
+	fmt.Fprintf(&b, "\n%s \"$@\"\n", zshFuncName(c))
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// GenBashDynamic writes a bash completion script
+// that uses the __complete command
+// to get the completion candidates.
+func (c *Command) genBashDynamic(w io.Writer) error {
+	var b strings.Builder
+	name := c.name()
+	fn := "_" + shellName(name)
+
+	fmt.Fprintf(&b, "# bash completion for %s\n\n", name)
+	fmt.Fprintf(&b, "%s() {\n", fn)
+	fmt.Fprintf(&b, "\tlocal cur out directive line\n")
+	fmt.Fprintf(&b, "\tcur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	fmt.Fprintf(&b, "\tout=$(%s %s \"${COMP_WORDS[@]:1:COMP_CWORD}\" 2>/dev/null) || return\n", name, completeCmd)
+	fmt.Fprintf(&b, "\tdirective=\"${out##*:}\"\n")
+	fmt.Fprintf(&b, "\tout=\"${out%%:*}\"\n\n")
+	fmt.Fprintf(&b, "\tCOMPREPLY=()\n")
+	fmt.Fprintf(&b, "\twhile IFS='' read -r line; do\n")
+	fmt.Fprintf(&b, "\t\t[[ -n \"${line}\" ]] && COMPREPLY+=(\"${line}\")\n")
+	fmt.Fprintf(&b, "\tdone <<< \"${out}\"\n")
+	fmt.Fprintf(&b, "\tif [[ ${#COMPREPLY[@]} -eq 0 && $((directive & %d)) -eq 0 ]]; then\n", compNoFiles)
+	fmt.Fprintf(&b, "\t\tCOMPREPLY=($(compgen -f -- \"${cur}\"))\n")
+	fmt.Fprintf(&b, "\tfi\n")
+	fmt.Fprintf(&b, "}\n\n")
+	fmt.Fprintf(&b, "complete -F %s %s\n", fn, name)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// GenZshDynamic writes a zsh completion script
+// that uses the __complete command
+// to get the completion candidates.
+func (c *Command) genZshDynamic(w io.Writer) error {
+	var b strings.Builder
+	name := c.name()
+	fn := zshFuncName(c)
+
+	fmt.Fprintf(&b, "#compdef %s\n", name)
+	fmt.Fprintf(&b, "\n%s() {\n", fn)
+	fmt.Fprintf(&b, "\tlocal out directive\n")
+	fmt.Fprintf(&b, "\tlocal -a candidates\n")
+	fmt.Fprintf(&b, "\tout=$(%s %s \"${(@)words[2,CURRENT]}\" 2>/dev/null) || return\n", name, completeCmd)
+	fmt.Fprintf(&b, "\tdirective=${out##*:}\n")
+	fmt.Fprintf(&b, "\tout=${out%%:*}\n\n")
+	fmt.Fprintf(&b, "\tcandidates=(${(@f)out})\n")
+	fmt.Fprintf(&b, "\tif (( ${#candidates} > 0 )); then\n")
+	fmt.Fprintf(&b, "\t\tcompadd -- \"${candidates[@]}\"\n")
+	fmt.Fprintf(&b, "\t\treturn\n")
+	fmt.Fprintf(&b, "\tfi\n")
+	fmt.Fprintf(&b, "\tif (( (directive & %d) == 0 )); then\n", compNoFiles)
+	fmt.Fprintf(&b, "\t\t_files\n")
+	fmt.Fprintf(&b, "\tfi\n")
+	fmt.Fprintf(&b, "}\n")
+	fmt.Fprintf(&b, "\n%s \"$@\"\n", fn)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// CompletionFlags returns the flags
+// accepted by the Command,
+// including the flags automatically added
+// by the package,
+// and the help flag
+// (if auto help is enabled),
+// and a function to call
+// when the flags are no longer used.
+func (c *Command) completionFlags() (fs *flag.FlagSet, done func()) {
+	defined, done := c.definedFlags()
+
+	// the help flag is added to a copy,
+	// so the flag set of the Command is not modified
+	fs = flag.NewFlagSet(c.name(), flag.ContinueOnError)
+	defined.VisitAll(func(f *flag.Flag) {
+		if f.Name == helpAllFlagName {
+			return
+		}
+		fs.Var(f.Value, f.Name, f.Usage)
+		fs.Lookup(f.Name).DefValue = f.DefValue
+	})
+	if !c.root().DisableAutoHelp && fs.Lookup("help") == nil && fs.Lookup("h") == nil {
+		fs.Bool("help", false, "show help")
+	}
+	return fs, done
+}
+
+// CompleteCmd is the name of the hidden command
+// used by the shell to request completions.
+const completeCmd = "__complete"
+
+// Completion directives,
+// written as the last line of the output
+// of the __complete command.
+const (
+	compDefault = 0 // the shell can use its default completion
+	compNoFiles = 1 // the shell should not complete file names
+)
+
+// Complete writes the completion candidates
+// for the last argument in args,
+// one per line,
+// followed by a line with the completion directive.
+func (c *Command) complete(args []string) error {
+	var toComplete string
+	if len(args) > 0 {
+		toComplete = args[len(args)-1]
+		args = args[:len(args)-1]
+	}
+
+	cmd := c
+	fs, done := cmd.completionFlags()
+	defer done()
+	var pos []string
+	var valueOf *flag.Flag // flag whose value is completed
+	helpMode := false      // completing after the help keyword
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if helpMode {
+			child, ok := cmd.child(a)
+			if !ok || !child.completable() {
+				// no more commands
+				cmd = nil
+				break
+			}
+			cmd = child
+			continue
+		}
+		if a == "--" {
+			pos = append(pos, args[i+1:]...)
+			break
+		}
+		if len(a) > 1 && a[0] == '-' {
+			name := strings.TrimLeft(a, "-")
+			if strings.Contains(name, "=") {
+				continue
+			}
+			if f := fs.Lookup(name); f != nil && !isBoolFlag(f) {
+				i++
+				if i == len(args) {
+					valueOf = f
+				}
+			}
+			continue
+		}
+		if len(pos) == 0 {
+			if child, ok := cmd.child(a); ok && child.completable() {
+				cmd = child
+				fs, done = cmd.completionFlags()
+				defer done()
+				continue
+			}
+			if cmd.hasChildren() && cmd.isHelp(a) {
+				helpMode = true
+				continue
+			}
+		}
+		pos = append(pos, a)
+	}
+	if helpMode {
+		return c.completeHelp(cmd, toComplete)
+	}
+
+	var candidates []string
+	directive := compDefault
+	switch {
+	case valueOf != nil:
+		for _, v := range cmd.flagValuesOf(valueOf) {
+			if strings.HasPrefix(v, toComplete) {
+				candidates = append(candidates, v)
+			}
+		}
+		if len(candidates) > 0 {
+			directive = compNoFiles
+		}
+	case strings.HasPrefix(toComplete, "-"):
+		for _, f := range flagNames(fs) {
+			if strings.HasPrefix(f, toComplete) {
+				candidates = append(candidates, f)
+			}
+		}
+		directive = compNoFiles
+	case len(pos) == 0 && cmd.hasChildren():
+		for _, n := range cmd.completableChildren() {
+			if strings.HasPrefix(n, cmd.fold(toComplete)) {
+				candidates = append(candidates, n)
+			}
+		}
+		directive = compNoFiles
+	}
+	if len(cmd.ValidArgs) > 0 && valueOf == nil && !strings.HasPrefix(toComplete, "-") && cmd.acceptsValidArg(len(pos)) {
+		for _, v := range cmd.ValidArgs {
+			if strings.HasPrefix(v, toComplete) {
+				candidates = append(candidates, v)
+			}
+		}
+		directive = compNoFiles
+	}
+	if cmd.ValidArgsFunc != nil && valueOf == nil && !strings.HasPrefix(toComplete, "-") {
+		candidates = append(candidates, cmd.ValidArgsFunc(cmd, pos, toComplete)...)
+		directive = compNoFiles
+	}
+
+	var b strings.Builder
+	for _, s := range candidates {
+		fmt.Fprintf(&b, "%s\n", s)
+	}
+	fmt.Fprintf(&b, ":%d\n", directive)
+	_, err := io.WriteString(c.Stdout(), b.String())
+	return err
+}
+
+// FlagValuesOf returns the valid values
+// of a flag of the Command,
+// as defined by MarkFlagValues
+// or by an OutputFormat.
+// A shorthand shares the valid values
+// of its flag.
+func (c *Command) flagValuesOf(f *flag.Flag) []string {
+	if v, ok := c.flagValues[c.flagName(f.Name)]; ok {
+		return v
+	}
+	if of, ok := f.Value.(*OutputFormat); ok {
+		return of.Values()
+	}
+	return nil
+}
+
+// CompleteHelp writes the completion candidates
+// for the words after the help keyword,
+// i.e. the children commands of cmd.
+func (c *Command) completeHelp(cmd *Command, toComplete string) error {
+	var b strings.Builder
+	if cmd != nil {
+		for _, n := range cmd.completableChildren() {
+			if strings.HasPrefix(n, cmd.fold(toComplete)) {
+				fmt.Fprintf(&b, "%s\n", n)
+			}
+		}
+	}
+	fmt.Fprintf(&b, ":%d\n", compNoFiles)
+	_, err := io.WriteString(c.Stdout(), b.String())
+	return err
+}
+
+// AcceptsValidArg returns true if the positional argument
+// at position i
+// can take a value from the Command's ValidArgs.
+func (c *Command) acceptsValidArg(i int) bool {
+	if i == 0 {
+		return true
+	}
+	specs := c.ArgSpecs()
+	if len(specs) == 0 {
+		return false
+	}
+	return specs[0].Variadic
+}
+
+// Completable returns true if the Command
+// can be used in a command line,
+// i.e. it is not a help topic.
+func (c *Command) completable() bool {
+	return !c.isTopic()
+}
+
+// CompletableChildren returns the names
+// of the children Commands
+// that can be used in a command line,
+// and that are not hidden
+// or gated.
+func (c *Command) completableChildren() []string {
+	var names []string
+	for _, n := range c.children() {
+		child, ok := c.child(n)
+		if !ok {
+			continue
+		}
+		if child.Hidden || child.Deprecated != "" || !child.available() || !child.completable() {
+			continue
+		}
+		names = append(names, n)
+	}
+	return names
+}
+
+// FlagNames returns the names of the flags
+// defined in a flag set,
+// in lexicographic order,
+// as written in the command line.
+func flagNames(fs *flag.FlagSet) []string {
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) {
+		names = append(names, flagArg(f.Name))
+	})
+	sort.Strings(names)
+	return names
+}
+
+// FlagArg returns the name of a flag
+// as written in the command line,
+// i.e. prefixed by a double dash,
+// or by a single dash
+// if it is a one-letter name
+// (for example a shorthand).
+func flagArg(name string) string {
+	if len(name) == 1 {
+		return "-" + name
+	}
+	return "--" + name
+}
+
+// IsBoolFlag returns true if the flag
+// does not require a value.
+func isBoolFlag(f *flag.Flag) bool {
+	if bf, ok := f.Value.(interface{ IsBoolFlag() bool }); ok {
+		return bf.IsBoolFlag()
+	}
+	return false
+}
+
+// ShellName returns a name
+// that can be used as a shell function name.
+func shellName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// ZshFlags returns the zsh argument specifications
+// of the flags defined in a flag set.
+func zshFlags(fs *flag.FlagSet) []string {
+	var flags []string
+	fs.VisitAll(func(f *flag.Flag) {
+		value, usage := flag.UnquoteUsage(f)
+		usage = strings.Join(strings.Fields(usage), " ")
+		usage = strings.NewReplacer("[", "\\[", "]", "\\]").Replace(usage)
+		spec := fmt.Sprintf("%s[%s]", flagArg(f.Name), usage)
+		if !isBoolFlag(f) {
+			if value == "" {
+				value = "value"
+			}
+			spec += ":" + value + ":"
+		}
+		flags = append(flags, zshQuote(spec))
+	})
+	return flags
+}
+
+// ZshFuncName returns the name of the zsh function
+// used to complete a Command.
+func zshFuncName(c *Command) string {
+	return "_" + shellName(strings.ReplaceAll(c.longName(), " ", "_"))
+}
+
+// ZshHelpFuncName returns the name of the zsh function
+// used to complete the words after the help keyword.
+func zshHelpFuncName(c *Command) string {
+	return zshFuncName(c) + "__help"
+}
+
+// ZshHelp writes the zsh function
+// that completes the words after the help keyword
+// with the children commands of a Command.
+func zshHelp(b *strings.Builder, c *Command) {
+	fmt.Fprintf(b, "\n%s() {\n", zshHelpFuncName(c))
+	fmt.Fprintf(b, "\tlocal curcontext=\"$curcontext\" state line\n")
+	fmt.Fprintf(b, "\tlocal -a commands\n")
+	fmt.Fprintf(b, "\tcommands=(\n")
+	children := c.completableChildren()
+	for _, n := range children {
+		child, _ := c.child(n)
+		desc := strings.ReplaceAll(n, ":", "\\:") + ":" + strings.Join(strings.Fields(child.Short), " ")
+		fmt.Fprintf(b, "\t\t%s\n", zshQuote(desc))
+	}
+	fmt.Fprintf(b, "\t)\n\n")
+	fmt.Fprintf(b, "\t_arguments -C \\\n\t\t'1: :->command' \\\n\t\t'*:: :->argument'\n\n")
+	fmt.Fprintf(b, "\tcase $state in\n")
+	fmt.Fprintf(b, "\tcommand)\n")
+	fmt.Fprintf(b, "\t\t_describe -t commands %s commands\n", zshQuote(c.longName()+" help commands"))
+	fmt.Fprintf(b, "\t\t;;\n")
+	fmt.Fprintf(b, "\targument)\n")
+	fmt.Fprintf(b, "\t\tcase ${words[1]:l} in\n")
+	for _, n := range children {
+		child, _ := c.child(n)
+		if !child.hasChildren() {
+			continue
+		}
+		fmt.Fprintf(b, "\t\t%s)\n", zshQuote(n))
+		fmt.Fprintf(b, "\t\t\t%s\n", zshHelpFuncName(child))
+		fmt.Fprintf(b, "\t\t\t;;\n")
+	}
+	fmt.Fprintf(b, "\t\tesac\n")
+	fmt.Fprintf(b, "\t\t;;\n")
+	fmt.Fprintf(b, "\tesac\n")
+	fmt.Fprintf(b, "}\n")
+}
+
+// ZshQuote returns s as a single quoted zsh string.
+func zshQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// ZshArgs returns the zsh argument specifications
+// of the positional arguments of a Command.
+// Arguments without valid values
+// are completed with the zsh default completion.
+func zshArgs(c *Command) []string {
+	specs := c.ArgSpecs()
+	if len(specs) == 0 {
+		return []string{"'*::argument:_default'"}
+	}
+
+	action := "_default"
+	if len(c.ValidArgs) > 0 {
+		action = "(" + strings.Join(c.ValidArgs, " ") + ")"
+	}
+
+	var args []string
+	for i, s := range specs {
+		name := strings.ReplaceAll(s.Name, ":", "\\:")
+		switch {
+		case s.Variadic:
+			args = append(args, zshQuote("*:"+name+":"+action))
+		case s.Optional:
+			args = append(args, zshQuote("::"+name+":"+action))
+		default:
+			args = append(args, zshQuote(":"+name+":"+action))
+		}
+		if s.Variadic {
+			// no more arguments after a variadic one
+			break
+		}
+		if i == 0 {
+			// valid args are only for the first argument
+			action = "_default"
+		}
+	}
+	return args
+}
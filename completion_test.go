@@ -0,0 +1,309 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package command_test
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/js-arias/command"
+)
+
+func TestGenBashCompletion(t *testing.T) {
+	app := newApp()
+	var b strings.Builder
+	if err := app.GenBashCompletion(&b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	script := b.String()
+
+	want := []string{
+		"_app() {",
+		`"app cmd"|"app cmd cat"|"app cmd echo"|"app cmd error"|"app error"|"app hello")`,
+		"\t\"app\")\n\t\tcommands=\"cmd error hello\"\n",
+		"\t\"app cmd\")\n\t\tcommands=\"cat echo error\"\n",
+		"\t\"app hello\")\n\t\tcommands=\"\"\n\t\tflags=\"--help --message --utf8\"\n",
+		"\t\tif [[ -z \"${help}\" && \"${word}\" == \"help\" ]]; then\n",
+		"\tif [[ -n \"${help}\" ]]; then\n\t\tCOMPREPLY=($(compgen -W \"${commands}\" -- \"${cur}\"))\n",
+		"complete -F _app app\n",
+	}
+	for _, w := range want {
+		if !strings.Contains(script, w) {
+			t.Errorf("bash completion: expecting %q in script:\n%s", w, script)
+		}
+	}
+
+	if strings.Contains(script, "topic") {
+		t.Errorf("bash completion: help topics should not be completed:\n%s", script)
+	}
+}
+
+func TestCompletionBuiltinFlags(t *testing.T) {
+	app := newApp()
+	app.Version = "v1.0.0"
+	app.Add(&command.Command{
+		Usage:           "fetch",
+		Run:             echoToStderrRun,
+		RequiresNetwork: true,
+	})
+
+	var b strings.Builder
+	if err := app.GenBashCompletion(&b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	script := b.String()
+	want := []string{
+		"\t\"app\")\n\t\tcommands=\"cmd error fetch hello\"\n\t\tflags=\"--help --offline --version\"\n",
+		"\t\"app cmd\")\n\t\tcommands=\"cat echo error\"\n\t\tflags=\"--help --offline\"\n",
+	}
+	for _, w := range want {
+		if !strings.Contains(script, w) {
+			t.Errorf("bash completion: expecting %q in script:\n%s", w, script)
+		}
+	}
+
+	testExecute(t, app, []string{"__complete", "--"}, "", "--help\n--offline\n--version\n:1", "")
+	testExecute(t, app, []string{"__complete", "hello", "--"}, "", "--help\n--message\n--offline\n--utf8\n:1", "")
+
+	app.DisableAutoHelp = true
+	testExecute(t, app, []string{"__complete", "hello", "--"}, "", "--message\n--offline\n--utf8\n:1", "")
+}
+
+func TestGenCompletionFromRun(t *testing.T) {
+	app := newApp()
+	var verbose bool
+	app.SetFlags = func(c *command.Command) {
+		c.PersistentFlags().BoolVar(&verbose, "verbose", false, "print more information")
+	}
+	var shell string
+	var script strings.Builder
+	app.Add(&command.Command{
+		Usage: "completion [--shell <shell>]",
+		Short: "print a completion script",
+		Run: func(c *command.Command, args []string) error {
+			if err := c.Root().GenBashCompletion(&script); err != nil {
+				return err
+			}
+			if err := c.Root().GenZshCompletion(io.Discard); err != nil {
+				return err
+			}
+			help := c.Flags().Lookup("help") != nil
+			fmt.Fprintf(c.Stdout(), "shell: %s verbose: %v help: %v\n", shell, verbose, help)
+			return nil
+		},
+		SetFlags: func(c *command.Command) {
+			c.Flags().StringVar(&shell, "shell", "bash", "completion shell")
+		},
+	})
+
+	testExecute(t, app, []string{"--verbose", "completion", "--shell", "zsh"}, "", "shell: zsh verbose: true help: false", "")
+
+	want := "\t\"app\")\n\t\tcommands=\"cmd completion error hello\"\n\t\tflags=\"--help --verbose\"\n"
+	if !strings.Contains(script.String(), want) {
+		t.Errorf("bash completion: expecting %q in script:\n%s", want, script.String())
+	}
+}
+
+func TestGenZshCompletion(t *testing.T) {
+	app := newApp()
+	app.Add(&command.Command{
+		Usage:     "service [-f|--force] <action> <name>",
+		Short:     "manage a service",
+		ValidArgs: []string{"start", "stop"},
+		Run:       func(c *command.Command, args []string) error { return nil },
+		SetFlags: func(c *command.Command) {
+			c.BoolFlag(new(bool), "force", "f", false, "force the action")
+		},
+	})
+	var b strings.Builder
+	if err := app.GenZshCompletion(&b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	script := b.String()
+
+	want := []string{
+		"#compdef app\n",
+		"'cmd:a collection of commands'",
+		"'hello:print a hello message'",
+		"_app_cmd() {",
+		"'cat:print stdin'",
+		"'echo:print its arguments'",
+		"'error:always return an error'",
+		"\t\t'cat')\n\t\t\t_app_cmd_cat\n",
+		"_app_hello() {\n\t_arguments \\\n\t\t'--help[show help]' \\\n\t\t'--message[sets the greeting message]:string:' \\\n\t\t'--utf8[print an utf8 message]' \\\n",
+		"_app_cmd_echo() {\n\t_arguments \\\n\t\t'--help[show help]' \\\n\t\t'*:argument:_default'\n}\n",
+		"_app_cmd_cat() {\n\t_arguments \\\n\t\t'--help[show help]' \\\n\t\t'*::argument:_default'\n}\n",
+		"_app_service() {\n\t_arguments \\\n\t\t'-f[force the action]' \\\n\t\t'--force[force the action]' \\\n\t\t'--help[show help]' \\\n\t\t':action:(start stop)' \\\n\t\t':name:_default'\n}\n",
+		"\t\t'help')\n\t\t\t_app__help\n",
+		"_app__help() {",
+		"\t\t'cmd')\n\t\t\t_app_cmd__help\n",
+		"_app_cmd__help() {",
+		"\n_app \"$@\"\n",
+	}
+	for _, w := range want {
+		if !strings.Contains(script, w) {
+			t.Errorf("zsh completion: expecting %q in script:\n%s", w, script)
+		}
+	}
+
+	if strings.Contains(script, "topic") {
+		t.Errorf("zsh completion: help topics should not be completed:\n%s", script)
+	}
+}
+
+func TestGenDynamicCompletion(t *testing.T) {
+	app := newApp()
+	app.DynamicCompletion = true
+
+	var b strings.Builder
+	if err := app.GenBashCompletion(&b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	script := b.String()
+	want := []string{
+		"_app() {",
+		`out=$(app __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null) || return`,
+		`directive="${out##*:}"`,
+		"complete -F _app app\n",
+	}
+	for _, w := range want {
+		if !strings.Contains(script, w) {
+			t.Errorf("bash completion: expecting %q in script:\n%s", w, script)
+		}
+	}
+	if strings.Contains(script, "hello") {
+		t.Errorf("bash completion: commands should not be hardcoded:\n%s", script)
+	}
+
+	b.Reset()
+	if err := app.GenZshCompletion(&b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	script = b.String()
+	want = []string{
+		"#compdef app\n",
+		`out=$(app __complete "${(@)words[2,CURRENT]}" 2>/dev/null) || return`,
+		"(( (directive & 1) == 0 ))",
+		"\n_app \"$@\"\n",
+	}
+	for _, w := range want {
+		if !strings.Contains(script, w) {
+			t.Errorf("zsh completion: expecting %q in script:\n%s", w, script)
+		}
+	}
+	if strings.Contains(script, "hello") {
+		t.Errorf("zsh completion: commands should not be hardcoded:\n%s", script)
+	}
+}
+
+func TestComplete(t *testing.T) {
+	tests := map[string]struct {
+		args []string
+		out  string
+	}{
+		"root commands": {
+			args: []string{""},
+			out:  "cmd\nerror\nget\nhello\nreq\nservice\ntag\n:1",
+		},
+		"children with prefix": {
+			args: []string{"cmd", "e"},
+			out:  "echo\nerror\n:1",
+		},
+		"flags": {
+			args: []string{"hello", "--"},
+			out:  "--help\n--message\n--utf8\n:1",
+		},
+		"flags with prefix": {
+			args: []string{"hello", "-m"},
+			out:  ":1",
+		},
+		"leaf without completion": {
+			args: []string{"cmd", "cat", ""},
+			out:  ":0",
+		},
+		"dynamic completion": {
+			args: []string{"get", "--id", "x", "a", ""},
+			out:  "a:1\na:2\n:1",
+		},
+		"valid args": {
+			args: []string{"service", ""},
+			out:  "start\nstop\nrestart\n:1",
+		},
+		"valid args with prefix": {
+			args: []string{"service", "st"},
+			out:  "start\nstop\n:1",
+		},
+		"valid args already given": {
+			args: []string{"service", "stop", ""},
+			out:  ":0",
+		},
+		"variadic valid args": {
+			args: []string{"tag", "red", "b"},
+			out:  "blue\n:1",
+		},
+		"shorthands": {
+			args: []string{"tag", "-"},
+			out:  "--all\n--help\n-a\n:1",
+		},
+		"map flag value": {
+			args: []string{"req", "--header", ""},
+			out:  ":0",
+		},
+		"help commands": {
+			args: []string{"help", ""},
+			out:  "cmd\nerror\nget\nhello\nreq\nservice\ntag\n:1",
+		},
+		"help children": {
+			args: []string{"help", "cmd", "e"},
+			out:  "echo\nerror\n:1",
+		},
+		"help of a leaf": {
+			args: []string{"help", "hello", ""},
+			out:  ":1",
+		},
+	}
+
+	app := newApp()
+	app.Add(&command.Command{
+		Usage:     "service <action>",
+		Run:       func(c *command.Command, args []string) error { return nil },
+		ValidArgs: []string{"start", "stop", "restart"},
+	})
+	app.Add(&command.Command{
+		Usage:     "tag [-a|--all] <color>...",
+		Run:       func(c *command.Command, args []string) error { return nil },
+		ValidArgs: []string{"red", "green", "blue"},
+		SetFlags: func(c *command.Command) {
+			c.BoolFlag(new(bool), "all", "a", false, "tag all items")
+		},
+	})
+	app.Add(&command.Command{
+		Usage: "get [--id <value>] <item>...",
+		Short: "get items",
+		Run:   func(c *command.Command, args []string) error { return nil },
+		SetFlags: func(c *command.Command) {
+			c.Flags().String("id", "", "")
+		},
+		ValidArgsFunc: func(c *command.Command, args []string, toComplete string) []string {
+			prefix := strings.Join(args, " ")
+			return []string{prefix + ":1", prefix + ":2"}
+		},
+	})
+	app.Add(&command.Command{
+		Usage: "req [--header <key=value>]...",
+		Run:   func(c *command.Command, args []string) error { return nil },
+		SetFlags: func(c *command.Command) {
+			c.Flags().Var(kvValue{}, "header", "request header")
+		},
+	})
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			args := append([]string{"__complete"}, test.args...)
+			testExecute(t, app, args, "", test.out, "")
+		})
+	}
+}
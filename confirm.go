@@ -0,0 +1,82 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ErrNotInteractive is the error returned by Confirm
+// when the standard input is not a terminal.
+var ErrNotInteractive = errors.New("standard input is not a terminal")
+
+// Confirm writes a yes/no question
+// in the Command's standard error,
+// and reads the answer
+// from the Command's standard input.
+// It returns true if the answer is "y" or "yes",
+// and false if the answer is "n", "no",
+// or empty.
+// Other answers are not accepted,
+// and the question is asked again.
+//
+// If the standard input is a file
+// that is not a terminal
+// (for example, a redirected input),
+// it returns ErrNotInteractive,
+// instead of waiting for an answer.
+func (c *Command) Confirm(prompt string) (bool, error) {
+	in := c.Stdin()
+	if f, ok := in.(*os.File); ok && !isTerminal(f) {
+		return false, fmt.Errorf("%s: %w", c.longName(), ErrNotInteractive)
+	}
+
+	for {
+		fmt.Fprintf(c.Stderr(), "%s [y/N]: ", prompt)
+		ln, err := readLine(in)
+		switch strings.ToLower(strings.TrimSpace(ln)) {
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		case "":
+			if err != nil && !errors.Is(err, io.EOF) {
+				return false, err
+			}
+			if err != nil {
+				// end of input without answer
+				fmt.Fprintf(c.Stderr(), "\n")
+			}
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("%s: invalid answer %q", c.longName(), strings.TrimSpace(ln))
+		}
+	}
+}
+
+// ReadLine reads a line from r,
+// one byte at a time,
+// so no input after the line is consumed.
+func readLine(r io.Reader) (string, error) {
+	var b strings.Builder
+	buf := make([]byte, 1)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if buf[0] == '\n' {
+				return b.String(), nil
+			}
+			b.WriteByte(buf[0])
+		}
+		if err != nil {
+			return b.String(), err
+		}
+	}
+}
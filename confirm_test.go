@@ -0,0 +1,88 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package command_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/js-arias/command"
+)
+
+func TestConfirm(t *testing.T) {
+	tests := map[string]struct {
+		in     string
+		out    string
+		errOut string
+	}{
+		"yes": {
+			in:     "y\n",
+			out:    "removed",
+			errOut: "remove file? [y/N]:",
+		},
+		"yes (caps)": {
+			in:     "YES\n",
+			out:    "removed",
+			errOut: "remove file? [y/N]:",
+		},
+		"no": {
+			in:     "no\n",
+			out:    "canceled",
+			errOut: "remove file? [y/N]:",
+		},
+		"empty answer": {
+			in:     "\n",
+			out:    "canceled",
+			errOut: "remove file? [y/N]:",
+		},
+		"invalid answer": {
+			in:     "maybe\ny\n",
+			out:    "removed",
+			errOut: "remove file? [y/N]: remove file? [y/N]:",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := &command.Command{
+				Usage: "remove <file>",
+				Run: func(c *command.Command, args []string) error {
+					ok, err := c.Confirm("remove file?")
+					if err != nil {
+						return err
+					}
+					if !ok {
+						fmt.Fprintf(c.Stdout(), "canceled\n")
+						return nil
+					}
+					fmt.Fprintf(c.Stdout(), "removed\n")
+					return nil
+				},
+			}
+			testExecute(t, c, []string{"file"}, test.in, test.out, test.errOut)
+		})
+	}
+}
+
+func TestConfirmNotInteractive(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "input.txt")
+	if err := os.WriteFile(name, []byte("y\n"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer f.Close()
+
+	c := &command.Command{Usage: "remove <file>"}
+	c.SetStdin(f)
+	if _, err := c.Confirm("remove file?"); !errors.Is(err, command.ErrNotInteractive) {
+		t.Errorf("error: got %v, want %v", err, command.ErrNotInteractive)
+	}
+}
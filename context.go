@@ -0,0 +1,57 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"context"
+	"errors"
+)
+
+// ExecuteContext executes the Command
+// as Execute,
+// using ctx as the context of the execution.
+// The context is available to the commands
+// with the Context method.
+func (c *Command) ExecuteContext(ctx context.Context, args []string) error {
+	r := c.root()
+	prev := r.ctx
+	r.ctx = ctx
+	defer func() {
+		r.ctx = prev
+	}()
+	return c.Execute(args)
+}
+
+// Context returns the context
+// of the current execution.
+// If the Command is not executed
+// with ExecuteContext,
+// it returns a background context.
+func (c *Command) Context() context.Context {
+	if ctx := c.root().ctx; ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
+
+// RunWithTimeout runs the Command
+// with a context that is canceled
+// after the Command's Timeout.
+func (c *Command) runWithTimeout(args []string) error {
+	r := c.root()
+	ctx, cancel := context.WithTimeout(c.Context(), c.Timeout)
+	defer cancel()
+
+	defer func(parent context.Context) {
+		r.ctx = parent
+	}(r.ctx)
+	r.ctx = ctx
+
+	err := c.run(args)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return ErrDeadlineExceeded
+	}
+	return err
+}
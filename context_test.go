@@ -0,0 +1,109 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package command_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/js-arias/command"
+)
+
+type ctxKey struct{}
+
+func TestExecuteContext(t *testing.T) {
+	app := newApp()
+	app.Add(&command.Command{
+		Usage: "value",
+		Run: func(c *command.Command, args []string) error {
+			v := c.Context().Value(ctxKey{})
+			fmt.Fprintf(c.Stdout(), "%v", v)
+			return nil
+		},
+	})
+
+	testExecute(t, app, []string{"value"}, "", "<nil>", "")
+
+	var out strings.Builder
+	app.SetStdout(&out)
+	ctx := context.WithValue(context.Background(), ctxKey{}, "from context")
+	if err := app.ExecuteContext(ctx, []string{"value"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := out.String(), "from context"; got != want {
+		t.Errorf("context value: got %q, want %q", got, want)
+	}
+
+	// the context is not kept after the execution
+	testExecute(t, app, []string{"value"}, "", "<nil>", "")
+}
+
+func TestExecuteContextNested(t *testing.T) {
+	app := newApp()
+	app.Add(&command.Command{
+		Usage: "value",
+		Run: func(c *command.Command, args []string) error {
+			fmt.Fprintf(c.Stdout(), "%v\n", c.Context().Value(ctxKey{}))
+			return nil
+		},
+	})
+	app.Add(&command.Command{
+		Usage: "wrap",
+		Run: func(c *command.Command, args []string) error {
+			ctx := context.WithValue(c.Context(), ctxKey{}, "inner")
+			if err := c.Root().ExecuteContext(ctx, []string{"value"}); err != nil {
+				return err
+			}
+			fmt.Fprintf(c.Stdout(), "%v\n", c.Context().Value(ctxKey{}))
+			return nil
+		},
+	})
+
+	var out strings.Builder
+	app.SetStdout(&out)
+	ctx := context.WithValue(context.Background(), ctxKey{}, "outer")
+	if err := app.ExecuteContext(ctx, []string{"wrap"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := out.String(), "inner\nouter\n"; got != want {
+		t.Errorf("context value: got %q, want %q", got, want)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	newCmd := func(timeout time.Duration) *command.Command {
+		return &command.Command{
+			Usage:   "wait",
+			Timeout: timeout,
+			Run: func(c *command.Command, args []string) error {
+				select {
+				case <-c.Context().Done():
+					return c.Context().Err()
+				case <-time.After(50 * time.Millisecond):
+				}
+				fmt.Fprintf(c.Stdout(), "done")
+				return nil
+			},
+		}
+	}
+
+	testExecute(t, newCmd(0), nil, "", "done", "")
+	testExecute(t, newCmd(time.Second), nil, "", "done", "")
+
+	err := newCmd(time.Millisecond).ExecuteContext(context.Background(), nil)
+	if !errors.Is(err, command.ErrDeadlineExceeded) {
+		t.Errorf("error %v: expecting ErrDeadlineExceeded", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error %v: expecting context.DeadlineExceeded", err)
+	}
+	if got, want := err.Error(), "wait: command deadline exceeded"; got != want {
+		t.Errorf("error: got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DecodeStdin reads the Command's standard input
+// and decodes it into v.
+//
+// The format of the input is taken
+// from the --input-format flag,
+// or the --format flag,
+// if any of them is defined by the Command
+// and it is not empty.
+// Otherwise the format is detected
+// from the content of the input.
+//
+// JSON input is always accepted.
+// Other formats
+// (like YAML or TOML)
+// require a decoding function
+// defined in the Decoders field of the root Command.
+func (c *Command) DecodeStdin(v any) error {
+	data, err := io.ReadAll(c.Stdin())
+	if err != nil {
+		return err
+	}
+
+	format := c.inputFormat()
+	if format == "" {
+		format = sniffFormat(data)
+	}
+	format = strings.ToLower(format)
+
+	decode := c.root().Decoders[format]
+	if decode == nil && format == "json" {
+		decode = json.Unmarshal
+	}
+	if decode == nil {
+		return c.UsageError(fmt.Sprintf("unsupported input format %q", format))
+	}
+	if err := decode(data, v); err != nil {
+		return c.UsageError(fmt.Sprintf("invalid %s input: %v", format, err))
+	}
+	return nil
+}
+
+// InputFormat returns the format of the input
+// as defined by a flag.
+func (c *Command) inputFormat() string {
+	if c.flags == nil {
+		return ""
+	}
+	for _, name := range []string{"input-format", "format"} {
+		if f := c.flags.Lookup(name); f != nil {
+			if v := f.Value.String(); v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+// SniffFormat returns the format of data
+// based on its content.
+// If the format cannot be determined,
+// it returns "json".
+func sniffFormat(data []byte) string {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return "json"
+	}
+	if data[0] == '{' {
+		return "json"
+	}
+
+	first := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		first = data[:i]
+	}
+	first = bytes.TrimSpace(first)
+	if data[0] == '[' {
+		// a TOML table header is a single name
+		// enclosed in brackets
+		name := bytes.Trim(first, "[]")
+		if bytes.HasSuffix(first, []byte("]")) && len(name) > 0 && !bytes.ContainsAny(name, "\"',{[ ") {
+			return "toml"
+		}
+		return "json"
+	}
+	if bytes.HasPrefix(first, []byte("---")) || bytes.HasPrefix(first, []byte("- ")) {
+		return "yaml"
+	}
+	if i := bytes.IndexByte(first, '='); i > 0 && !bytes.Contains(first[:i], []byte(":")) {
+		return "toml"
+	}
+	if bytes.Contains(first, []byte(":")) {
+		return "yaml"
+	}
+	return "json"
+}
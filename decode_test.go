@@ -0,0 +1,133 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package command_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/js-arias/command"
+)
+
+func TestDecodeStdin(t *testing.T) {
+	tests := map[string]struct {
+		args []string
+		in   string
+		out  string
+	}{
+		"json object": {
+			in:  `{"name": "json"}`,
+			out: "json: json",
+		},
+		"json array": {
+			in:  `["a", "b"]`,
+			out: "json: [a b]",
+		},
+		"yaml": {
+			in:  "name: value\nother: value",
+			out: "yaml",
+		},
+		"yaml document": {
+			in:  "---\nname: value",
+			out: "yaml",
+		},
+		"toml": {
+			in:  "name = \"value\"",
+			out: "toml",
+		},
+		"toml table": {
+			in:  "[server]\nport = 80",
+			out: "toml",
+		},
+		"explicit format": {
+			args: []string{"--input-format", "yaml"},
+			in:   `{"name": "json"}`,
+			out:  "yaml",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			testExecute(t, newDecodeApp(), append([]string{"decode"}, test.args...), test.in, test.out, "")
+		})
+	}
+}
+
+func TestDecodeStdinError(t *testing.T) {
+	tests := map[string]struct {
+		args   []string
+		in     string
+		errMsg string
+	}{
+		"invalid json": {
+			in:     `{"name": `,
+			errMsg: "app decode: invalid json input: unexpected end of JSON input",
+		},
+		"unsupported format": {
+			args:   []string{"--input-format", "xml"},
+			in:     "<name>xml</name>",
+			errMsg: `app decode: unsupported input format "xml"`,
+		},
+		"decoder error": {
+			args:   []string{"--input-format", "toml"},
+			in:     "name",
+			errMsg: "app decode: invalid toml input: bad toml",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			app := newDecodeApp()
+			app.SetStdin(strings.NewReader(test.in))
+			testExecuteError(t, app, append([]string{"decode"}, test.args...), test.errMsg)
+		})
+	}
+}
+
+func newDecodeApp() *command.Command {
+	app := &command.Command{
+		Usage: "app <command> [<argument>...]",
+		Decoders: map[string]func([]byte, any) error{
+			"yaml": func(data []byte, v any) error {
+				*(v.(*any)) = "yaml"
+				return nil
+			},
+			"toml": func(data []byte, v any) error {
+				if string(data) == "name" {
+					return errors.New("bad toml")
+				}
+				*(v.(*any)) = "toml"
+				return nil
+			},
+		},
+	}
+
+	var format string
+	app.Add(&command.Command{
+		Usage: "decode [--input-format <format>]",
+		Run: func(c *command.Command, args []string) error {
+			var v any
+			if err := c.DecodeStdin(&v); err != nil {
+				return err
+			}
+			if m, ok := v.(map[string]any); ok {
+				fmt.Fprintf(c.Stdout(), "json: %v\n", m["name"])
+				return nil
+			}
+			if a, ok := v.([]any); ok {
+				fmt.Fprintf(c.Stdout(), "json: %v\n", a)
+				return nil
+			}
+			fmt.Fprintf(c.Stdout(), "%v\n", v)
+			return nil
+		},
+		SetFlags: func(c *command.Command) {
+			c.Flags().StringVar(&format, "input-format", "", "")
+		},
+	})
+	return app
+}
@@ -0,0 +1,103 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ErrUnknownCommand is the error
+// wrapped by the usage error returned by Execute
+// when a command is not found,
+// so it can be detected with errors.Is.
+var ErrUnknownCommand = errors.New("unknown command")
+
+// ErrDeadlineExceeded is the error
+// returned by Execute
+// when Run takes longer than the Command's Timeout.
+// It wraps context.DeadlineExceeded.
+var ErrDeadlineExceeded error = deadlineError{}
+
+type deadlineError struct{}
+
+func (deadlineError) Error() string {
+	return "command deadline exceeded"
+}
+
+func (deadlineError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// An ExitError is an error
+// with an exit code.
+// When an ExitError is returned by a Command,
+// Main exits with the code of the error.
+type ExitError struct {
+	code int
+	msg  string
+}
+
+// NewExitError returns an error
+// with the given exit code and message.
+// It is intended to be returned
+// by a Command's Run function.
+func NewExitError(code int, msg string) error {
+	return &ExitError{
+		code: code,
+		msg:  msg,
+	}
+}
+
+// Code returns the exit code of the error.
+func (e *ExitError) Code() int {
+	return e.code
+}
+
+func (e *ExitError) Error() string {
+	return e.msg
+}
+
+// ExitCode returns the exit code
+// of an error returned by Execute.
+func exitCode(err error) int {
+	var ee *ExitError
+	if errors.As(err, &ee) {
+		return ee.Code()
+	}
+	return 1
+}
+
+// A jsonError is an error
+// printed as a JSON object.
+type jsonError struct {
+	Command string `json:"command"`
+	Error   string `json:"error"`
+	Usage   string `json:"usage,omitempty"`
+}
+
+// PrintJSONError prints an error
+// returned by Execute
+// as a JSON object
+// in the standard error.
+func (c *Command) printJSONError(err error) {
+	from := c
+	if c.failed != nil {
+		from = c.failed
+	}
+	je := jsonError{Error: err.Error()}
+	var ue usageError
+	if errors.As(err, &ue) && ue.c != nil {
+		from = ue.c
+		je.Usage = strings.TrimSpace(from.UsageString())
+	}
+	je.Command = from.longName()
+
+	enc := json.NewEncoder(c.Stderr())
+	enc.SetEscapeHTML(false)
+	enc.Encode(je)
+}
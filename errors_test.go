@@ -0,0 +1,215 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package command_test
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/js-arias/command"
+)
+
+func TestExitError(t *testing.T) {
+	app := newApp()
+	app.Add(&command.Command{
+		Usage: "find <name>",
+		Run: func(c *command.Command, args []string) error {
+			return command.NewExitError(2, "not found")
+		},
+	})
+
+	err := app.Execute([]string{"find", "item"})
+	if err == nil {
+		t.Fatalf("expecting error")
+	}
+	if got, want := err.Error(), "app find: not found"; got != want {
+		t.Errorf("error: got %q, want %q", got, want)
+	}
+	var ee *command.ExitError
+	if !errors.As(err, &ee) {
+		t.Fatalf("error %v: expecting an ExitError", err)
+	}
+	if ee.Code() != 2 {
+		t.Errorf("exit code: got %d, want %d", ee.Code(), 2)
+	}
+}
+
+func TestErrorHandler(t *testing.T) {
+	defer func(args []string) {
+		os.Args = args
+	}(os.Args)
+	os.Args = []string{"app", "error"}
+
+	var got error
+	app := newApp()
+	app.ErrorHandler = func(c *command.Command, err error) {
+		got = err
+	}
+	app.Main()
+
+	if got == nil {
+		t.Fatalf("expecting error")
+	}
+	if want := "app error: an error from a command"; got.Error() != want {
+		t.Errorf("error: got %q, want %q", got.Error(), want)
+	}
+}
+
+func TestSilenceErrors(t *testing.T) {
+	app := newApp()
+	app.SilenceErrors = true
+	app.Add(&command.Command{
+		Usage:      "fetch",
+		Short:      "fetch data",
+		Run:        echoToStderrRun,
+		Deprecated: `use "pull" instead`,
+	})
+
+	testExecute(t, app, []string{"fetch", "data"}, "", "", "data")
+	if err := app.Execute([]string{"error"}); err == nil {
+		t.Errorf("expecting error")
+	}
+}
+
+func TestErrUnknownCommand(t *testing.T) {
+	tests := map[string]struct {
+		args []string
+		msg  string
+	}{
+		"root": {
+			args: []string{"unknown"},
+			msg:  "app unknown: unknown command",
+		},
+		"child": {
+			args: []string{"cmd", "ech"},
+			msg:  `app cmd ech: unknown command. Did you mean "echo"?`,
+		},
+		"help topic": {
+			args: []string{"topic"},
+			msg:  "app topic: unknown command",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := newApp().Execute(test.args)
+			if !errors.Is(err, command.ErrUnknownCommand) {
+				t.Fatalf("error %v: expecting ErrUnknownCommand", err)
+			}
+			if got := err.Error(); got != test.msg {
+				t.Errorf("error: got %q, want %q", got, test.msg)
+			}
+		})
+	}
+
+	err := newApp().Execute([]string{"error"})
+	if errors.Is(err, command.ErrUnknownCommand) {
+		t.Errorf("error %v: unexpected ErrUnknownCommand", err)
+	}
+}
+
+func TestMainOutput(t *testing.T) {
+	if test := os.Getenv("COMMAND_TEST_MAIN"); test != "" {
+		app := newApp()
+		app.NoColor = true
+		switch test {
+		case "prefix":
+			app.ErrorPrefix = "fatal: "
+		case "silence usage":
+			app.SilenceUsage = true
+		case "silence errors":
+			app.SilenceErrors = true
+		case "json", "json usage":
+			app.ErrorFormat = "json"
+		case "json usage func":
+			app.ErrorFormat = "json"
+			app.UsageFunc = func(c *command.Command) string {
+				return "custom usage of " + c.Usage
+			}
+		case "json nested":
+			app.ErrorFormat = "json"
+			app.Add(&command.Command{
+				Usage: "wrap",
+				Short: "run error",
+				Run: func(c *command.Command, args []string) error {
+					return c.Root().Execute([]string{"error"})
+				},
+			})
+		case "config usage error":
+			app.ConfigFunc = func(c *command.Command) (map[string]string, error) {
+				return nil, c.UsageError("bad config")
+			}
+		}
+		for i, a := range os.Args {
+			if a == "--" {
+				os.Args = append([]string{"app"}, os.Args[i+1:]...)
+				break
+			}
+		}
+		app.Main()
+		return
+	}
+
+	tests := map[string]struct {
+		args []string
+		out  string
+	}{
+		"prefix": {
+			args: []string{"error"},
+			out:  "fatal: app error: an error from a command.",
+		},
+		"silence usage": {
+			args: []string{"cmd", "error"},
+			out:  "app cmd error: expecting arguments",
+		},
+		"silence errors": {
+			args: []string{"cmd", "error"},
+			out:  "usage: app cmd error <argument>...\nRun \"app help cmd error\" for details.",
+		},
+		"config usage error": {
+			args: []string{"hello"},
+			out:  "app: bad config\nusage: app <command> [<argument>...]\nRun \"app help\" for details.",
+		},
+		"parent usage": {
+			args: []string{"cmd", "unknown"},
+			out:  "app cmd unknown: unknown command\nusage: app cmd <command> [<argument>...]\nRun \"app help cmd\" for details.",
+		},
+		"json": {
+			args: []string{"error"},
+			out:  `{"command":"app error","error":"app error: an error from a command"}`,
+		},
+		"json usage": {
+			args: []string{"cmd", "error"},
+			out:  `{"command":"app cmd error","error":"app cmd error: expecting arguments","usage":"usage: app cmd error <argument>..."}`,
+		},
+		"json nested": {
+			args: []string{"wrap"},
+			out:  `{"command":"app wrap","error":"app wrap: app error: an error from a command"}`,
+		},
+		"json usage func": {
+			args: []string{"cmd", "error"},
+			out:  `{"command":"app cmd error","error":"app cmd error: expecting arguments","usage":"custom usage of error <argument>..."}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			args := append([]string{"-test.run=^TestMainOutput$", "--"}, test.args...)
+			cmd := exec.Command(os.Args[0], args...)
+			cmd.Env = append(os.Environ(), "COMMAND_TEST_MAIN="+name)
+			out, err := cmd.CombinedOutput()
+			var ee *exec.ExitError
+			if !errors.As(err, &ee) || ee.ExitCode() != 1 {
+				t.Fatalf("expecting exit code 1, got %v", err)
+			}
+			if got := strings.TrimSpace(string(out)); got != test.out {
+				t.Errorf("output: got %q, want %q", got, test.out)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// An Example is an usage example
+// of a Command.
+type Example struct {
+	// Command is the command line
+	// of the example,
+	// including the application name.
+	Command string
+
+	// Output is the expected output
+	// of the command line.
+	Output string
+}
+
+// Args returns the arguments
+// of the example's command line
+// after the application name.
+// Single and double quotes
+// can be used to include spaces in an argument.
+func (e Example) Args() ([]string, error) {
+	args, err := splitArgs(e.Command)
+	if err != nil {
+		return nil, fmt.Errorf("example %q: %v", e.Command, err)
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("example %q: empty command line", e.Command)
+	}
+	return args[1:], nil
+}
+
+// ExampleCases returns the examples
+// defined in the Command's Examples.
+// Each example is a line that starts with "$ ",
+// with the command line,
+// followed by the lines of the expected output,
+// up to an empty line
+// or the next example.
+// The indentation of the command line
+// is removed from the output lines,
+// other spaces are kept.
+// Other lines are ignored.
+func (c *Command) ExampleCases() []Example {
+	var examples []Example
+	var out []string
+	var indent string
+	inExample := false
+	end := func() {
+		if !inExample {
+			return
+		}
+		examples[len(examples)-1].Output = strings.Join(out, "\n")
+		out = nil
+		inExample = false
+	}
+	for _, ln := range strings.Split(c.Examples, "\n") {
+		ln = strings.TrimSuffix(ln, "\r")
+		if cmd := strings.TrimLeft(ln, " \t"); strings.HasPrefix(cmd, "$ ") {
+			end()
+			indent = ln[:len(ln)-len(cmd)]
+			examples = append(examples, Example{Command: strings.TrimSpace(cmd[2:])})
+			inExample = true
+			continue
+		}
+		if strings.TrimSpace(ln) == "" {
+			end()
+			continue
+		}
+		if inExample {
+			out = append(out, strings.TrimPrefix(ln, indent))
+		}
+	}
+	end()
+	return examples
+}
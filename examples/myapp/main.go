@@ -24,6 +24,7 @@ func init() {
 	app.Add(errCmd)
 	app.Add(hello)
 	app.Add(topic)
+	app.Add(tree)
 }
 
 // Usually Main is reduced to run Main method of the root command.
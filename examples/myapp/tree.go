@@ -0,0 +1,30 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package main
+
+import "github.com/js-arias/command"
+
+var depth int
+
+var tree = &command.Command{
+	Usage: "tree [--depth <number>]",
+	Short: "print the tree of commands",
+	Long: `
+Command tree prints the tree of commands of the application.
+
+Flags are:
+
+	--depth <number>
+		Print only the indicated number of levels of the tree. Commands
+		with truncated children are marked with an ellipsis. If zero, all
+		levels will be printed.
+	`,
+	Run: func(c *command.Command, args []string) error {
+		return app.PrintTree(c.Stdout(), depth)
+	},
+	SetFlags: func(c *command.Command) {
+		c.Flags().IntVar(&depth, "depth", 0, "")
+	},
+}
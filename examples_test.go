@@ -0,0 +1,50 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package command_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/js-arias/command"
+)
+
+func TestExampleCases(t *testing.T) {
+	c := &command.Command{
+		Usage: "say <argument>...",
+		Examples: `
+Print a message:
+
+	$ app say hello
+	hello
+
+	$ app say "hello world" again
+	hello world
+	again
+	$ app say
+	$ app say "  spaces  "
+	  spaces  
+`,
+	}
+
+	want := []command.Example{
+		{Command: "app say hello", Output: "hello"},
+		{Command: `app say "hello world" again`, Output: "hello world\nagain"},
+		{Command: "app say", Output: ""},
+		{Command: `app say "  spaces  "`, Output: "  spaces  "},
+	}
+	got := c.ExampleCases()
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("examples: got %q, want %q", got, want)
+	}
+
+	args, err := got[1].Args()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"say", "hello world", "again"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args: got %q, want %q", args, want)
+	}
+}
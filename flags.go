@@ -0,0 +1,950 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BindEnv binds a flag to an environment variable.
+// If the flag is not set in the command line,
+// and the environment variable is defined,
+// the flag takes the value of the environment variable.
+// That is,
+// the command line takes precedence over the environment,
+// and the environment takes precedence
+// over the flag default value.
+//
+// Usually it is called in the SetFlags function.
+func (c *Command) BindEnv(flagName, envVar string) {
+	if c.envVars == nil {
+		c.envVars = make(map[string]string)
+	}
+	c.envVars[flagName] = envVar
+}
+
+// A FlagSpec is the specification
+// of a flag of a Command.
+type FlagSpec struct {
+	// Name of the flag.
+	Name string
+
+	// Usage message of the flag.
+	Usage string
+
+	// Default value of the flag
+	// (as text).
+	Default string
+
+	// IsBool is true if the flag
+	// does not require a value.
+	IsBool bool
+}
+
+// FlagSpecs returns the specifications
+// of the flags defined by the Command,
+// including the flags automatically added
+// by the package,
+// in lexicographic order.
+// If the Command is not executed,
+// the flags are defined in a new flag set,
+// so the Command is not modified.
+func (c *Command) FlagSpecs() []FlagSpec {
+	fs, done := c.definedFlags()
+	defer done()
+
+	var specs []FlagSpec
+	fs.VisitAll(func(f *flag.Flag) {
+		if f.Name == helpAllFlagName {
+			return
+		}
+		specs = append(specs, FlagSpec{
+			Name:    f.Name,
+			Usage:   f.Usage,
+			Default: f.DefValue,
+			IsBool:  isBoolFlag(f),
+		})
+	})
+	return specs
+}
+
+// DefinedFlags returns the flag set
+// of the Command,
+// and a function to call
+// when the flag set is no longer used.
+// If the flags are not defined
+// (i.e. the Command is not executed),
+// they are defined until done is called,
+// so the values already parsed
+// are never reset.
+func (c *Command) definedFlags() (fs *flag.FlagSet, done func()) {
+	if c.flags != nil {
+		return c.flags, func() {}
+	}
+	done = c.saveFlags()
+	c.initFlags()
+	return c.flags, done
+}
+
+// FlagValue returns the current value
+// of a flag of the Command
+// as a string,
+// and true if the flag is defined.
+// As the flags are defined during Execute,
+// it returns false if it is called
+// before the Command is executed.
+func (c *Command) FlagValue(name string) (string, bool) {
+	if c.flags == nil {
+		return "", false
+	}
+	f := c.lookupFlag(name)
+	if f == nil {
+		return "", false
+	}
+	return f.Value.String(), true
+}
+
+// FlagChanged returns true
+// if the named flag was set
+// during the execution of the Command,
+// either in the command line
+// (using the flag name or its shorthand),
+// or from an environment variable
+// or a configuration file.
+// It returns false if the flag is not defined,
+// or if it is called
+// before the Command is executed.
+func (c *Command) FlagChanged(name string) bool {
+	if c.flags == nil {
+		return false
+	}
+	f := c.lookupFlag(name)
+	if f == nil {
+		return false
+	}
+	name = c.flagName(f.Name)
+	changed := false
+	c.flags.Visit(func(v *flag.Flag) {
+		if c.flagName(v.Name) == name {
+			changed = true
+		}
+	})
+	return changed
+}
+
+// FlagsInOrder returns the names of the flags
+// set in the command line of the Command,
+// in the order in which they were given.
+// A flag given multiple times
+// is repeated in the list,
+// and a shorthand is reported
+// with the name of its flag.
+// Flags set by an environment variable
+// or a configuration file
+// are not included.
+func (c *Command) FlagsInOrder() []string {
+	return append([]string(nil), c.flagOrder...)
+}
+
+// ParsedOrder returns the names of the flags
+// found in the parsed arguments,
+// in command line order.
+func (c *Command) parsedOrder(args []string) []string {
+	end := len(args) - len(c.flags.Args())
+	var names []string
+	for i := 0; i < end; i++ {
+		a := args[i]
+		if a == "--" {
+			break
+		}
+		name := strings.TrimLeft(a, "-")
+		if len(name) == len(a) || len(a)-len(name) > 2 {
+			continue
+		}
+		name, _, hasValue := strings.Cut(name, "=")
+		f := c.flags.Lookup(name)
+		if f == nil {
+			continue
+		}
+		names = append(names, c.flagName(f.Name))
+		if !hasValue && !isBoolFlag(f) {
+			i++
+		}
+	}
+	return names
+}
+
+// PersistentFlags returns the current set
+// of persistent flags of the Command.
+// Persistent flags are accepted
+// by the Command and all of its descendants.
+// A descendant flag with the same name
+// shadows the persistent flag.
+//
+// It must be called in the SetFlags function.
+func (c *Command) PersistentFlags() *flag.FlagSet {
+	return c.pflags
+}
+
+// PersistentFlagSet returns the persistent flags
+// of the Command.
+// If the flags are not defined,
+// they are defined in a new flag set.
+func (c *Command) persistentFlagSet() *flag.FlagSet {
+	if c.pflags != nil {
+		return c.pflags
+	}
+	defer c.saveFlags()()
+	c.initFlags()
+	return c.pflags
+}
+
+// AddPersistentFlags adds to the flag set of the Command
+// the persistent flags of the Command
+// and its ancestors.
+func (c *Command) addPersistentFlags() {
+	c.inherited = nil
+	for p := c; p != nil; p = p.parent {
+		fs := c.pflags
+		if p != c {
+			fs = p.persistentFlagSet()
+		}
+		fs.VisitAll(func(f *flag.Flag) {
+			if c.flags.Lookup(f.Name) != nil {
+				return
+			}
+			c.flags.Var(f.Value, f.Name, f.Usage)
+			c.flags.Lookup(f.Name).DefValue = f.DefValue
+			if p == c {
+				return
+			}
+			if c.inherited == nil {
+				c.inherited = make(map[string]bool)
+			}
+			c.inherited[f.Name] = true
+		})
+	}
+}
+
+// IsInherited returns true
+// if a flag of the Command
+// is a persistent flag of an ancestor.
+func (c *Command) isInherited(f *flag.Flag) bool {
+	return c.inherited[f.Name]
+}
+
+// InheritFlagsFrom defines in the Command
+// the flags of other Command.
+// The flags share the values
+// with the flags of the other Command,
+// so it is intended for wrapper commands
+// that forward its execution to other Command.
+// Built-in flags are not inherited.
+// It panics if a flag of the other Command
+// is already defined in the Command.
+//
+// It must be called in the SetFlags function.
+func (c *Command) InheritFlagsFrom(other *Command) {
+	fs, done := other.definedFlags()
+	defer done()
+
+	fs.VisitAll(func(f *flag.Flag) {
+		if f.Name == offlineFlag || f.Name == versionFlagName || f.Name == helpAllFlagName {
+			return
+		}
+		if c.flags.Lookup(f.Name) != nil {
+			msg := fmt.Sprintf("command %q: inheriting flags from %q: flag %q already defined", c.longName(), other.longName(), f.Name)
+			panic(msg)
+		}
+		c.flags.Var(f.Value, f.Name, f.Usage)
+		if long, ok := other.shorthands[f.Name]; ok {
+			if c.shorthands == nil {
+				c.shorthands = make(map[string]string)
+			}
+			c.shorthands[f.Name] = long
+		}
+	})
+}
+
+// MarkFlagDeprecated marks a flag as deprecated.
+// If the flag is set,
+// a warning with the given message
+// is printed in the Command's standard error.
+// The flag works as usual.
+//
+// Usually it is called in the SetFlags function.
+func (c *Command) MarkFlagDeprecated(name, message string) {
+	if c.deprecatedFlags == nil {
+		c.deprecatedFlags = make(map[string]string)
+	}
+	c.deprecatedFlags[name] = message
+}
+
+// MarkFlagValueDeprecated marks a value of a flag as deprecated.
+// If after parsing the flags
+// the flag has the deprecated value,
+// a warning with the given message
+// is printed in the Command's standard error.
+// The Command is executed as usual.
+//
+// Usually it is called in the SetFlags function.
+func (c *Command) MarkFlagValueDeprecated(flag, value, message string) {
+	if c.deprecatedValues == nil {
+		c.deprecatedValues = make(map[string]map[string]string)
+	}
+	if c.deprecatedValues[flag] == nil {
+		c.deprecatedValues[flag] = make(map[string]string)
+	}
+	c.deprecatedValues[flag][value] = message
+}
+
+// MarkFlagValues sets the valid values of a flag.
+// If the flag is set
+// with a value that is not in the list,
+// the Command returns an usage error.
+// The values are shown in the help of the flag,
+// and used for shell completion.
+//
+// Usually it is called in the SetFlags function.
+func (c *Command) MarkFlagValues(name string, values ...string) {
+	if c.flagValues == nil {
+		c.flagValues = make(map[string][]string)
+	}
+	c.flagValues[name] = values
+}
+
+// FlagGroup assigns the named flags
+// to a group,
+// so they are printed in its own section
+// of the help,
+// with the group name as header.
+// Flags that are not assigned to a group
+// are printed in the "Flags:" section.
+// Groups are printed in the order
+// in which they were first defined.
+//
+// Usually it is called in the SetFlags function.
+func (c *Command) FlagGroup(group string, names ...string) {
+	for i, g := range c.flagGroups {
+		if g.name == group {
+			c.flagGroups[i].flags = append(c.flagGroups[i].flags, names...)
+			return
+		}
+	}
+	c.flagGroups = append(c.flagGroups, flagGroup{name: group, flags: names})
+}
+
+// A flagGroup is a named group of flags
+// printed in its own section of the help.
+type flagGroup struct {
+	name  string
+	flags []string
+}
+
+// MarkFlagsRequiredTogether marks a group of flags
+// that must be used together.
+// If any flag of the group is set,
+// and any other flag of the group is not set,
+// the Command returns an usage error.
+//
+// Usually it is called in the SetFlags function.
+func (c *Command) MarkFlagsRequiredTogether(names ...string) {
+	if len(names) < 2 {
+		return
+	}
+	c.requiredTogether = append(c.requiredTogether, names)
+}
+
+// SetFromEnv sets the flags
+// bound to an environment variable,
+// that are not set in the command line.
+func (c *Command) setFromEnv() error {
+	if len(c.envVars) == 0 {
+		return nil
+	}
+
+	set := make(map[string]bool)
+	c.flags.Visit(func(f *flag.Flag) {
+		set[c.flagName(f.Name)] = true
+	})
+
+	var names []string
+	for name := range c.envVars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if set[name] || c.flags.Lookup(name) == nil {
+			continue
+		}
+		env := c.envVars[name]
+		v, ok := os.LookupEnv(env)
+		if !ok {
+			continue
+		}
+		if err := c.flags.Set(name, v); err != nil {
+			return c.UsageError(fmt.Sprintf("invalid value %q for flag --%s from %s: %v", v, name, env, err))
+		}
+	}
+	return nil
+}
+
+// Terminated returns true
+// if the parsing of the flags
+// of the Command
+// was stopped by a "--" terminator.
+func (c *Command) terminated(args []string) bool {
+	i := len(args) - len(c.flags.Args())
+	if i == 0 || args[i-1] != "--" {
+		return false
+	}
+	if i < 2 {
+		return true
+	}
+
+	// check if "--" is the value of a flag
+	prev := args[i-2]
+	if len(prev) < 2 || prev[0] != '-' || prev == "--" {
+		return true
+	}
+	name := strings.TrimLeft(prev, "-")
+	if strings.Contains(name, "=") {
+		return true
+	}
+	f := c.flags.Lookup(name)
+	return f == nil || isBoolFlag(f)
+}
+
+// FlagError returns the error
+// of a failed parsing of the flags.
+func (c *Command) flagError(err error) error {
+	for p := c; p != nil; p = p.parent {
+		if p.FlagErrorFunc != nil {
+			return p.FlagErrorFunc(c, err)
+		}
+	}
+	return c.UsageError(err.Error())
+}
+
+// HelpFlagArg returns the argument
+// used as a help flag.
+func helpFlagArg(args []string) string {
+	for _, a := range args {
+		if a == "--" {
+			break
+		}
+		name := strings.TrimLeft(a, "-")
+		if len(name) < len(a) && (name == "h" || name == "help") {
+			return a
+		}
+	}
+	return "-help"
+}
+
+// Intersperse reorders the arguments of a Command
+// so the flags are before the positional arguments.
+func (c *Command) intersperse(args []string) []string {
+	var flags, pos []string
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "--" {
+			pos = append(pos, args[i+1:]...)
+			break
+		}
+		if len(a) < 2 || a[0] != '-' {
+			pos = append(pos, a)
+			continue
+		}
+		flags = append(flags, a)
+		name := strings.TrimLeft(a, "-")
+		if strings.Contains(name, "=") {
+			continue
+		}
+		if f := c.lookupFlag(name); f != nil && !isBoolFlag(f) && i+1 < len(args) {
+			i++
+			flags = append(flags, args[i])
+		}
+	}
+	if len(pos) == 0 {
+		return flags
+	}
+	flags = append(flags, "--")
+	return append(flags, pos...)
+}
+
+// Traverse reorders the arguments of a Command
+// with children,
+// so the help flags given before the name of a child
+// are moved after that name,
+// and then, passed to the child.
+func (c *Command) traverse(args []string) []string {
+	var flags, help []string
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "--" {
+			return args
+		}
+		if len(a) < 2 || a[0] != '-' {
+			if _, ok := c.child(a); !ok || len(help) == 0 {
+				return args
+			}
+			out := append(flags, a)
+			out = append(out, help...)
+			return append(out, args[i+1:]...)
+		}
+		name := strings.TrimLeft(a, "-")
+		if (name == "h" || name == "help") && c.lookupFlag(name) == nil {
+			help = append(help, a)
+			continue
+		}
+		flags = append(flags, a)
+		if strings.Contains(name, "=") {
+			continue
+		}
+		if f := c.lookupFlag(name); f != nil && !isBoolFlag(f) && i+1 < len(args) {
+			i++
+			flags = append(flags, args[i])
+		}
+	}
+	return args
+}
+
+// NormalizeFunc returns the flag normalization function
+// defined in the Command or its ancestors.
+func (c *Command) normalizeFunc() func(string) string {
+	for p := c; p != nil; p = p.parent {
+		if p.FlagNormalizeFunc != nil {
+			return p.FlagNormalizeFunc
+		}
+	}
+	return nil
+}
+
+// LookupFlag returns the flag of the Command
+// with the given name,
+// or with the same normalized name
+// if a FlagNormalizeFunc is defined.
+func (c *Command) lookupFlag(name string) *flag.Flag {
+	if f := c.flags.Lookup(name); f != nil {
+		return f
+	}
+	norm := c.normalizeFunc()
+	if norm == nil {
+		return nil
+	}
+	n := norm(name)
+	var found *flag.Flag
+	c.flags.VisitAll(func(f *flag.Flag) {
+		if found == nil && norm(f.Name) == n {
+			found = f
+		}
+	})
+	return found
+}
+
+// NormalizeFlags replaces the names of the flags in args
+// with the names of the defined flags
+// that have the same normalized form.
+func (c *Command) normalizeFlags(args []string) []string {
+	if c.normalizeFunc() == nil {
+		return args
+	}
+
+	out := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "--" || len(a) < 2 || a[0] != '-' {
+			// end of flags
+			return append(out, args[i:]...)
+		}
+		name := strings.TrimLeft(a, "-")
+		dashes := a[:len(a)-len(name)]
+		name, value, hasValue := strings.Cut(name, "=")
+		f := c.lookupFlag(name)
+		if f != nil {
+			name = f.Name
+		}
+		if hasValue {
+			out = append(out, dashes+name+"="+value)
+			continue
+		}
+		out = append(out, dashes+name)
+		if f != nil && !isBoolFlag(f) && i+1 < len(args) {
+			i++
+			out = append(out, args[i])
+		}
+	}
+	return out
+}
+
+// SetFromConfig sets the flags
+// with the values read from a configuration file,
+// that are not set in the command line,
+// or by an environment variable.
+func (c *Command) setFromConfig() error {
+	var fn func(c *Command) (map[string]string, error)
+	for p := c; p != nil; p = p.parent {
+		if p.ConfigFunc != nil {
+			fn = p.ConfigFunc
+			break
+		}
+	}
+	if fn == nil {
+		return nil
+	}
+
+	cfg, err := fn(c)
+	if errors.Is(err, usageError{}) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", c.longName(), err)
+	}
+
+	set := make(map[string]bool)
+	c.flags.Visit(func(f *flag.Flag) {
+		set[c.flagName(f.Name)] = true
+	})
+
+	var names []string
+	for name := range cfg {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if set[name] || c.flags.Lookup(name) == nil {
+			continue
+		}
+		v := cfg[name]
+		if err := c.flags.Set(name, v); err != nil {
+			return c.UsageError(fmt.Sprintf("invalid value %q for flag --%s from configuration: %v", v, name, err))
+		}
+	}
+	return nil
+}
+
+// CheckFlags checks the flags of the Command
+// after the flags are parsed.
+func (c *Command) checkFlags() error {
+	if len(c.requiredTogether) > 0 {
+		set := make(map[string]bool)
+		c.flags.Visit(func(f *flag.Flag) {
+			set[c.flagName(f.Name)] = true
+		})
+		for _, g := range c.requiredTogether {
+			var missing []string
+			for _, name := range g {
+				if !set[name] {
+					missing = append(missing, "--"+name)
+				}
+			}
+			if len(missing) == 0 || len(missing) == len(g) {
+				continue
+			}
+			group := make([]string, 0, len(g))
+			for _, name := range g {
+				group = append(group, "--"+name)
+			}
+			return c.UsageError(fmt.Sprintf("flags %s must be set together; missing %s", strings.Join(group, ", "), strings.Join(missing, ", ")))
+		}
+	}
+
+	var valued []string
+	for name := range c.flagValues {
+		valued = append(valued, name)
+	}
+	sort.Strings(valued)
+	for _, name := range valued {
+		if !c.FlagChanged(name) {
+			continue
+		}
+		v := c.flags.Lookup(name).Value.String()
+		if !hasValue(c.flagValues[name], v) {
+			return c.UsageError(fmt.Sprintf("invalid value %q for flag --%s: must be one of: %s", v, name, strings.Join(c.flagValues[name], ", ")))
+		}
+	}
+
+	warned := make(map[string]bool)
+	c.flags.Visit(func(f *flag.Flag) {
+		name := c.flagName(f.Name)
+		if warned[name] {
+			return
+		}
+		if msg, ok := c.deprecatedFlags[name]; ok {
+			c.warn("Flag --%s is deprecated: %s", name, msg)
+			warned[name] = true
+		}
+	})
+
+	var names []string
+	for name := range c.deprecatedValues {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		f := c.flags.Lookup(name)
+		if f == nil {
+			continue
+		}
+		v := f.Value.String()
+		msg, ok := c.deprecatedValues[name][v]
+		if !ok {
+			continue
+		}
+		c.warn("Flag --%s value %q is deprecated: %s", name, v, msg)
+	}
+	return nil
+}
+
+// BoolFlag defines a bool flag
+// with a long name and a shorthand
+// (for example "verbose" and "v").
+// Both names set the same variable
+// and are shown together in the help.
+// If short is empty,
+// no shorthand is defined.
+//
+// It must be called in the SetFlags function.
+func (c *Command) BoolFlag(p *bool, name, short string, value bool, usage string) {
+	c.flags.BoolVar(p, name, value, usage)
+	c.shorthand(name, short)
+}
+
+// IntFlag defines an int flag
+// with a long name and a shorthand.
+//
+// It must be called in the SetFlags function.
+func (c *Command) IntFlag(p *int, name, short string, value int, usage string) {
+	c.flags.IntVar(p, name, value, usage)
+	c.shorthand(name, short)
+}
+
+// StringFlag defines a string flag
+// with a long name and a shorthand.
+//
+// It must be called in the SetFlags function.
+func (c *Command) StringFlag(p *string, name, short string, value string, usage string) {
+	c.flags.StringVar(p, name, value, usage)
+	c.shorthand(name, short)
+}
+
+// CountVar defines a counter flag.
+// Each time the flag is used
+// the value pointed by p is incremented,
+// so "-v -v -v" sets p to 3.
+// If the flag has a single letter name,
+// it can be repeated in a single argument,
+// so "-vvv" also sets p to 3.
+// An explicit value,
+// for example "-v=2",
+// sets the counter to that value.
+//
+// If InterspersedFlags is set,
+// the flags after the positional arguments
+// are also counted.
+//
+// It must be called in the SetFlags function.
+func (c *Command) CountVar(p *int, name, usage string) {
+	c.flags.Var((*countValue)(p), name, usage)
+}
+
+// A countValue is a flag value
+// that counts the times the flag is used.
+type countValue int
+
+func (cv *countValue) IsBoolFlag() bool { return true }
+func (cv *countValue) String() string {
+	if cv == nil {
+		return "0"
+	}
+	return strconv.Itoa(int(*cv))
+}
+
+func (cv *countValue) Set(s string) error {
+	if s == "true" {
+		*cv++
+		return nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	*cv = countValue(v)
+	return nil
+}
+
+// StringSliceVar defines a string slice flag
+// that can be used multiple times.
+// The first time the flag is used
+// its value replaces the default value,
+// and each following use
+// appends its value to the slice,
+// so "--tag a --tag b" sets p to [a b].
+//
+// It must be called in the SetFlags function.
+func (c *Command) StringSliceVar(p *[]string, name string, def []string, usage string) {
+	*p = append([]string(nil), def...)
+	c.flags.Var(&stringSliceValue{p: p}, name, usage)
+}
+
+// A stringSliceValue is a flag value
+// that accumulates strings.
+type stringSliceValue struct {
+	p   *[]string
+	set bool
+}
+
+func (sv *stringSliceValue) String() string {
+	if sv == nil || sv.p == nil {
+		return "[]"
+	}
+	return "[" + strings.Join(*sv.p, ",") + "]"
+}
+
+func (sv *stringSliceValue) Set(s string) error {
+	if !sv.set {
+		*sv.p = nil
+		sv.set = true
+	}
+	*sv.p = append(*sv.p, s)
+	return nil
+}
+
+// An OutputFormat is a flag value
+// that accepts one value
+// from a fixed set of formats,
+// for example "text" or "json".
+// Setting an invalid value
+// returns an error during the parsing of the flags.
+//
+// Use NewOutputFormat to create an OutputFormat,
+// and register it with c.Flags().Var.
+type OutputFormat struct {
+	values []string
+	value  string
+}
+
+// NewOutputFormat returns an OutputFormat
+// that accepts the given values.
+// The first value is the default.
+func NewOutputFormat(values ...string) *OutputFormat {
+	of := &OutputFormat{values: values}
+	if len(values) > 0 {
+		of.value = values[0]
+	}
+	return of
+}
+
+// Value returns the selected format.
+func (of *OutputFormat) Value() string {
+	return of.value
+}
+
+// Values returns the accepted formats.
+func (of *OutputFormat) Values() []string {
+	return append([]string(nil), of.values...)
+}
+
+func (of *OutputFormat) String() string {
+	if of == nil {
+		return ""
+	}
+	return of.value
+}
+
+func (of *OutputFormat) Set(s string) error {
+	if !hasValue(of.values, s) {
+		return fmt.Errorf("must be one of: %s", strings.Join(of.values, ", "))
+	}
+	of.value = s
+	return nil
+}
+
+// ExpandCounts expands the repeated counter flags
+// of the Command in args,
+// for example "-vvv" into "-v -v -v".
+func (c *Command) expandCounts(args []string) []string {
+	counters := false
+	c.flags.VisitAll(func(f *flag.Flag) {
+		if _, ok := f.Value.(*countValue); ok {
+			counters = true
+		}
+	})
+	if !counters {
+		return args
+	}
+
+	var expanded []string
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "--" || len(a) < 2 || a[0] != '-' {
+			// end of flags
+			return append(expanded, args[i:]...)
+		}
+		name := strings.TrimLeft(a, "-")
+		if strings.Contains(name, "=") {
+			expanded = append(expanded, a)
+			continue
+		}
+		f := c.flags.Lookup(name)
+		if f == nil && len(name) > 1 && strings.Count(name, name[:1]) == len(name) {
+			if cf := c.flags.Lookup(name[:1]); cf != nil {
+				if _, ok := cf.Value.(*countValue); ok {
+					for range name {
+						expanded = append(expanded, "-"+name[:1])
+					}
+					continue
+				}
+			}
+		}
+		expanded = append(expanded, a)
+		if f != nil && !isBoolFlag(f) && i+1 < len(args) {
+			i++
+			expanded = append(expanded, args[i])
+		}
+	}
+	return expanded
+}
+
+// HasValue returns true
+// if v is in the list of values.
+func hasValue(values []string, v string) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Shorthand defines short
+// as an alternative name of an already defined flag.
+func (c *Command) shorthand(name, short string) {
+	if short == "" {
+		return
+	}
+	f := c.flags.Lookup(name)
+	c.flags.Var(f.Value, short, f.Usage)
+	if c.shorthands == nil {
+		c.shorthands = make(map[string]string)
+	}
+	c.shorthands[short] = name
+}
+
+// FlagName returns the name of a flag
+// of the Command,
+// using the long name of a shorthand.
+func (c *Command) flagName(name string) string {
+	if long, ok := c.shorthands[name]; ok {
+		return long
+	}
+	return name
+}
@@ -0,0 +1,857 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package command_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/js-arias/command"
+)
+
+func TestFlagValueDeprecated(t *testing.T) {
+	tests := map[string]struct {
+		args []string
+		out  string
+		err  string
+	}{
+		"default value": {
+			out: "mode: fast",
+		},
+		"valid value": {
+			args: []string{"--mode", "slow"},
+			out:  "mode: slow",
+		},
+		"deprecated value": {
+			args: []string{"--mode", "legacy"},
+			out:  "mode: legacy",
+			err:  `Flag --mode value "legacy" is deprecated: use "slow" instead`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			testExecute(t, cmdWithMode(), test.args, "", test.out, test.err)
+		})
+	}
+}
+
+func TestFlagDeprecated(t *testing.T) {
+	newCmd := func() *command.Command {
+		c := cmdWithMode()
+		setFlags := c.SetFlags
+		c.SetFlags = func(c *command.Command) {
+			setFlags(c)
+			c.StringFlag(new(string), "speed", "s", "", "set the running speed")
+			c.MarkFlagDeprecated("speed", "use --mode instead")
+		}
+		return c
+	}
+
+	testExecute(t, newCmd(), nil, "", "mode: fast", "")
+	testExecute(t, newCmd(), []string{"--speed", "slow"}, "", "mode: fast", "Flag --speed is deprecated: use --mode instead")
+	testExecute(t, newCmd(), []string{"-s", "slow"}, "", "mode: fast", "Flag --speed is deprecated: use --mode instead")
+	testExecute(t, newCmd(), []string{"-s", "slow", "--speed", "slow"}, "", "mode: fast", "Flag --speed is deprecated: use --mode instead")
+}
+
+func cmdWithMode() *command.Command {
+	var mode string
+	return &command.Command{
+		Usage: "run [--mode <mode>]",
+		Short: "run in a given mode",
+		Run: func(c *command.Command, args []string) error {
+			fmt.Fprintf(c.Stdout(), "mode: %s\n", mode)
+			return nil
+		},
+		SetFlags: func(c *command.Command) {
+			c.Flags().StringVar(&mode, "mode", "fast", "set the running mode")
+			c.MarkFlagValueDeprecated("mode", "legacy", `use "slow" instead`)
+		},
+	}
+}
+
+func TestBindEnv(t *testing.T) {
+	t.Setenv("APP_MESSAGE", "environment")
+
+	tests := map[string]struct {
+		args []string
+		out  string
+	}{
+		"from environment": {
+			out: "hello, environment",
+		},
+		"from command line": {
+			args: []string{"--message", "flag"},
+			out:  "hello, flag",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := cmdWithFlags()
+			setFlags := c.SetFlags
+			c.SetFlags = func(c *command.Command) {
+				setFlags(c)
+				c.BindEnv("message", "APP_MESSAGE")
+			}
+			testExecute(t, c, test.args, "", test.out, "")
+		})
+	}
+
+	t.Setenv("APP_UTF8", "not-a-bool")
+	c := cmdWithFlags()
+	c.BindEnv("utf8", "APP_UTF8")
+	testExecuteError(t, c, nil, `hello: invalid value "not-a-bool" for flag --utf8 from APP_UTF8: parse error`)
+}
+
+func cmdWithToken() *command.Command {
+	var token string
+	return &command.Command{
+		Usage: "login [-t|--token <token>]",
+		Short: "login into a service",
+		Run: func(c *command.Command, args []string) error {
+			fmt.Fprintf(c.Stdout(), "token: %s\n", token)
+			return nil
+		},
+		SetFlags: func(c *command.Command) {
+			c.StringFlag(&token, "token", "t", "", "access token")
+		},
+	}
+}
+
+func TestBindEnvShorthand(t *testing.T) {
+	t.Setenv("APP_TOKEN", "fromenv")
+
+	newCmd := func() *command.Command {
+		c := cmdWithToken()
+		setFlags := c.SetFlags
+		c.SetFlags = func(c *command.Command) {
+			setFlags(c)
+			c.BindEnv("token", "APP_TOKEN")
+		}
+		return c
+	}
+
+	testExecute(t, newCmd(), nil, "", "token: fromenv", "")
+	testExecute(t, newCmd(), []string{"-t", "fromcli"}, "", "token: fromcli", "")
+	testExecute(t, newCmd(), []string{"--token", "fromcli"}, "", "token: fromcli", "")
+}
+
+func TestFlagsRequiredTogether(t *testing.T) {
+	newCmd := func() *command.Command {
+		var cert, key string
+		return &command.Command{
+			Usage: "serve [--cert <file> --key <file>]",
+			Short: "serve a site",
+			Run: func(c *command.Command, args []string) error {
+				fmt.Fprintf(c.Stdout(), "cert: %q key: %q\n", cert, key)
+				return nil
+			},
+			SetFlags: func(c *command.Command) {
+				c.StringFlag(&cert, "cert", "c", "", "certificate file")
+				c.StringFlag(&key, "key", "k", "", "key file")
+				c.MarkFlagsRequiredTogether("cert", "key")
+			},
+		}
+	}
+
+	testExecute(t, newCmd(), nil, "", `cert: "" key: ""`, "")
+	testExecute(t, newCmd(), []string{"--cert", "a.pem", "--key", "a.key"}, "", `cert: "a.pem" key: "a.key"`, "")
+	testExecute(t, newCmd(), []string{"-c", "a.pem", "--key", "a.key"}, "", `cert: "a.pem" key: "a.key"`, "")
+
+	msg := "serve: flags --cert, --key must be set together; missing --key"
+	testExecuteError(t, newCmd(), []string{"--cert", "a.pem"}, msg)
+	msg = "serve: flags --cert, --key must be set together; missing --cert"
+	testExecuteError(t, newCmd(), []string{"--key", "a.key"}, msg)
+	testExecuteError(t, newCmd(), []string{"-k", "a.key"}, msg)
+}
+
+func TestInheritFlagsFrom(t *testing.T) {
+	hello := cmdWithFlags()
+	app := &command.Command{
+		Usage: "app <command> [<argument>...]",
+	}
+	app.Add(&command.Command{
+		Usage: "run [--utf8] [--message <message>]",
+		Short: "run hello",
+		Run: func(c *command.Command, args []string) error {
+			return hello.Run(c, args)
+		},
+		SetFlags: func(c *command.Command) {
+			c.InheritFlagsFrom(hello)
+		},
+	})
+
+	testExecute(t, app, []string{"run", "--message", "wrapper"}, "", "hello, wrapper", "")
+
+	var out strings.Builder
+	app.SetStdout(&out)
+	if err := app.Execute([]string{"help", "run"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "    --message string sets the greeting message (default \"world\")\n"
+	if !strings.Contains(out.String(), want) {
+		t.Errorf("help: expecting %q in:\n%s", want, out.String())
+	}
+	// inheriting from an executed command
+	// keeps its parsed values
+	testExecute(t, hello, []string{"--message", "kept"}, "", "hello, kept", "")
+	run := &command.Command{
+		Usage: "run [--utf8] [--message <message>]",
+		Run: func(c *command.Command, args []string) error {
+			return hello.Run(c, args)
+		},
+		SetFlags: func(c *command.Command) {
+			c.InheritFlagsFrom(hello)
+		},
+	}
+	run.FlagSpecs()
+	if v, _ := hello.FlagValue("message"); v != "kept" {
+		t.Errorf("inherited flag value: got %q, want %q", v, "kept")
+	}
+}
+
+func TestInheritFlagsFromPanic(t *testing.T) {
+	defer func() {
+		msg := capturePanicMessage(recover())
+		want := `command "run": inheriting flags from "hello": flag "message" already defined`
+		if msg != want {
+			t.Errorf("panic %q, want %q", msg, want)
+		}
+	}()
+
+	hello := cmdWithFlags()
+	run := &command.Command{
+		Usage: "run",
+		Run: func(c *command.Command, args []string) error {
+			return hello.Run(c, args)
+		},
+		SetFlags: func(c *command.Command) {
+			c.Flags().String("message", "", "")
+			c.InheritFlagsFrom(hello)
+		},
+	}
+	run.Execute(nil)
+}
+
+func TestInterspersedFlags(t *testing.T) {
+	tests := map[string]struct {
+		args []string
+		out  string
+	}{
+		"flags first": {
+			args: []string{"--upper", "--sep", "+", "a", "b"},
+			out:  "A+B",
+		},
+		"flags after arguments": {
+			args: []string{"a", "--sep", "+", "b", "--upper"},
+			out:  "A+B",
+		},
+		"flag with equal": {
+			args: []string{"a", "b", "--sep=-"},
+			out:  "a-b",
+		},
+		"terminator": {
+			args: []string{"a", "--", "--upper", "-x"},
+			out:  "a --upper -x",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := cmdWithSep()
+			c.InterspersedFlags = true
+			testExecute(t, c, test.args, "", test.out, "")
+		})
+	}
+
+	// by default flags after arguments are not parsed
+	testExecute(t, cmdWithSep(), []string{"a", "--upper"}, "", "a --upper", "")
+}
+
+func cmdWithSep() *command.Command {
+	var upper bool
+	var sep string
+	return &command.Command{
+		Usage: "join [--upper] [--sep <separator>] <argument>...",
+		Short: "join its arguments",
+		Run: func(c *command.Command, args []string) error {
+			s := strings.Join(args, sep)
+			if upper {
+				s = strings.ToUpper(s)
+			}
+			fmt.Fprintf(c.Stdout(), "%s\n", s)
+			return nil
+		},
+		SetFlags: func(c *command.Command) {
+			c.Flags().BoolVar(&upper, "upper", false, "print in upper case")
+			c.Flags().StringVar(&sep, "sep", " ", "set the separator")
+		},
+	}
+}
+
+func TestTerminator(t *testing.T) {
+	testExecute(t, newApp(), []string{"cmd", "echo", "--", "--not-a-flag"}, "", "", "--not-a-flag")
+	testExecute(t, newApp(), []string{"cmd", "echo", "--", "--", "x"}, "", "", "-- x")
+	testExecute(t, cmdWithSep(), []string{"--sep", "--", "a", "b"}, "", "a--b", "")
+	testExecute(t, cmdWithSep(), []string{"--", "--upper", "a"}, "", "--upper a", "")
+
+	// arguments after the terminator are not commands
+	testExecuteError(t, newApp(), []string{"--", "hello"}, `app: unexpected argument "hello" after --`)
+	testExecuteError(t, newApp(), []string{"cmd", "--", "echo", "x"}, `app cmd: unexpected argument "echo" after --`)
+}
+
+func TestFlagErrorFunc(t *testing.T) {
+	app := newApp()
+	app.FlagErrorFunc = func(c *command.Command, err error) error {
+		msg := strings.TrimPrefix(err.Error(), "flag provided but not defined: ")
+		return c.UsageError(fmt.Sprintf("unknown flag %s; see %q", msg, "help"))
+	}
+
+	testExecuteError(t, app, []string{"hello", "--undef"}, `app hello: unknown flag -undef; see "help"`)
+	testExecute(t, app, []string{"hello", "--message", "you"}, "", "hello, you", "")
+
+	// without the function
+	testExecuteError(t, newApp(), []string{"hello", "--undef"}, "app hello: flag provided but not defined: -undef")
+}
+
+func TestFlagSpecs(t *testing.T) {
+	got := cmdWithFlags().FlagSpecs()
+	want := []command.FlagSpec{
+		{Name: "message", Usage: "sets the greeting message", Default: "world"},
+		{Name: "utf8", Usage: "print an utf8 message", Default: "false", IsBool: true},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("specs: got %v, want %v", got, want)
+	}
+	for i, s := range got {
+		if s != want[i] {
+			t.Errorf("spec %d: got %v, want %v", i, s, want[i])
+		}
+	}
+
+	if specs := (&command.Command{Usage: "empty"}).FlagSpecs(); len(specs) != 0 {
+		t.Errorf("specs: got %v, want no specs", specs)
+	}
+	// specs requested while running
+	// keep the parsed values
+	c := cmdWithFlags()
+	run := c.Run
+	c.Run = func(c *command.Command, args []string) error {
+		if specs := c.FlagSpecs(); len(specs) != len(want) {
+			t.Errorf("specs while running: got %v, want %v", specs, want)
+		}
+		return run(c, args)
+	}
+	testExecute(t, c, []string{"--message", "specs"}, "", "hello, specs", "")
+}
+
+func TestFlagValue(t *testing.T) {
+	c := &command.Command{
+		Usage: "greet [--name <name>]",
+		Run: func(c *command.Command, args []string) error {
+			name, _ := c.FlagValue("name")
+			_, ok := c.FlagValue("undefined")
+			fmt.Fprintf(c.Stdout(), "hello, %s %v\n", name, ok)
+			return nil
+		},
+		SetFlags: func(c *command.Command) {
+			c.Flags().String("name", "world", "set the name")
+		},
+	}
+
+	if _, ok := c.FlagValue("name"); ok {
+		t.Errorf("flag value: flag defined before Execute")
+	}
+	testExecute(t, c, []string{"--name", "you"}, "", "hello, you false", "")
+}
+
+var verboseHelp = `Print the verbose mode
+
+Usage:
+
+    app mode [--name <name>]
+
+Flags:
+
+    --name string    set the name (default "mode")
+
+Global Flags:
+
+    --verbose        print more information`
+
+func TestPersistentFlags(t *testing.T) {
+	newVerboseApp := func() *command.Command {
+		var verbose bool
+		var name string
+		app := newApp()
+		app.SetFlags = func(c *command.Command) {
+			c.PersistentFlags().BoolVar(&verbose, "verbose", false, "print more information")
+		}
+		app.Add(&command.Command{
+			Usage: "mode [--name <name>]",
+			Short: "print the verbose mode",
+			Run: func(c *command.Command, args []string) error {
+				fmt.Fprintf(c.Stdout(), "%s: %v\n", name, verbose)
+				return nil
+			},
+			SetFlags: func(c *command.Command) {
+				c.Flags().StringVar(&name, "name", "mode", "set the name")
+			},
+		})
+		return app
+	}
+
+	testExecute(t, newVerboseApp(), []string{"mode"}, "", "mode: false", "")
+	testExecute(t, newVerboseApp(), []string{"--verbose", "mode"}, "", "mode: true", "")
+	testExecute(t, newVerboseApp(), []string{"mode", "--verbose", "--name", "x"}, "", "x: true", "")
+	testExecute(t, newVerboseApp(), []string{"help", "mode"}, "", verboseHelp, "")
+}
+
+var persistentMapHelp = `Send a request
+
+Usage:
+
+    app req [--query <key=value>]...
+
+Flags:
+
+    --query value    query parameter
+
+Global Flags:
+
+    --header value   request header`
+
+func TestPersistentMapFlag(t *testing.T) {
+	app := newApp()
+	app.SetFlags = func(c *command.Command) {
+		c.PersistentFlags().Var(kvValue{}, "header", "request header")
+	}
+	app.Add(&command.Command{
+		Usage: "req [--query <key=value>]...",
+		Short: "send a request",
+		Run: func(c *command.Command, args []string) error {
+			v, _ := c.FlagValue("header")
+			fmt.Fprintf(c.Stdout(), "%s\n", v)
+			return nil
+		},
+		SetFlags: func(c *command.Command) {
+			c.Flags().Var(kvValue{}, "query", "query parameter")
+		},
+	})
+
+	testExecute(t, app, []string{"req", "--header", "a=b"}, "", "a=b", "")
+	testExecute(t, app, []string{"help", "req"}, "", persistentMapHelp, "")
+}
+
+func TestTraverseChildren(t *testing.T) {
+	newVerboseApp := func() *command.Command {
+		var verbose bool
+		app := newApp()
+		app.TraverseChildren = true
+		app.SetFlags = func(c *command.Command) {
+			c.PersistentFlags().BoolVar(&verbose, "verbose", false, "print more information")
+		}
+		app.Add(&command.Command{
+			Usage: "mode [--name <name>]",
+			Short: "print the verbose mode",
+			Run: func(c *command.Command, args []string) error {
+				fmt.Fprintf(c.Stdout(), "%v\n", verbose)
+				return nil
+			},
+		})
+		return app
+	}
+
+	testExecute(t, newVerboseApp(), []string{"--verbose", "mode"}, "", "true", "")
+	testExecute(t, newVerboseApp(), []string{"-h", "mode"}, "", "", "usage: app mode [--name <name>]")
+	testExecute(t, newVerboseApp(), []string{"--verbose", "--help", "mode"}, "", "", "usage: app mode [--name <name>]")
+
+	app := newVerboseApp()
+	testExecute(t, app, []string{"-h", "cmd", "echo"}, "", "", "usage: app cmd echo <argument>...")
+	testExecute(t, app, []string{"-h"}, "", "", strings.TrimSpace(app.HelpString()))
+	testExecute(t, app, []string{"-h", "unknown"}, "", "", strings.TrimSpace(app.HelpString()))
+
+	// by default the help flag is used by the parent
+	app = newVerboseApp()
+	app.TraverseChildren = false
+	testExecute(t, app, []string{"-h", "mode"}, "", "", strings.TrimSpace(app.HelpString()))
+}
+
+func TestConfigFunc(t *testing.T) {
+	t.Setenv("APP_MESSAGE", "environment")
+
+	tests := map[string]struct {
+		args []string
+		env  bool
+		out  string
+	}{
+		"from config": {
+			out: "hello, config",
+		},
+		"from environment": {
+			env: true,
+			out: "hello, environment",
+		},
+		"from command line": {
+			args: []string{"--message", "flag"},
+			env:  true,
+			out:  "hello, flag",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := cmdWithFlags()
+			setFlags := c.SetFlags
+			c.SetFlags = func(c *command.Command) {
+				setFlags(c)
+				if test.env {
+					c.BindEnv("message", "APP_MESSAGE")
+				}
+			}
+			c.ConfigFunc = func(c *command.Command) (map[string]string, error) {
+				return map[string]string{
+					"message": "config",
+					"unknown": "ignored",
+				}, nil
+			}
+			testExecute(t, c, test.args, "", test.out, "")
+		})
+	}
+
+	c := cmdWithFlags()
+	c.ConfigFunc = func(c *command.Command) (map[string]string, error) {
+		return map[string]string{"utf8": "maybe"}, nil
+	}
+	testExecuteError(t, c, nil, `hello: invalid value "maybe" for flag --utf8 from configuration: parse error`)
+}
+
+func TestConfigFuncShorthand(t *testing.T) {
+	newCmd := func() *command.Command {
+		c := cmdWithToken()
+		c.ConfigFunc = func(c *command.Command) (map[string]string, error) {
+			return map[string]string{"token": "fromcfg"}, nil
+		}
+		return c
+	}
+
+	testExecute(t, newCmd(), nil, "", "token: fromcfg", "")
+	testExecute(t, newCmd(), []string{"-t", "fromcli"}, "", "token: fromcli", "")
+	testExecute(t, newCmd(), []string{"--token", "fromcli"}, "", "token: fromcli", "")
+}
+
+func TestCountVar(t *testing.T) {
+	tests := map[string]struct {
+		args []string
+		out  string
+	}{
+		"no flag": {
+			out: "level 0: []",
+		},
+		"single flag": {
+			args: []string{"-v", "a"},
+			out:  "level 1: [a]",
+		},
+		"repeated flag": {
+			args: []string{"-v", "--v", "-v", "a"},
+			out:  "level 3: [a]",
+		},
+		"joined flags": {
+			args: []string{"-vvv", "a", "-vv"},
+			out:  "level 3: [a -vv]",
+		},
+		"explicit value": {
+			args: []string{"-v=5"},
+			out:  "level 5: []",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var level int
+			c := &command.Command{
+				Usage: "log [-v] <argument>...",
+				Run: func(c *command.Command, args []string) error {
+					fmt.Fprintf(c.Stdout(), "level %d: %v\n", level, args)
+					return nil
+				},
+				SetFlags: func(c *command.Command) {
+					c.CountVar(&level, "v", "increase verbosity")
+				},
+			}
+			testExecute(t, c, test.args, "", test.out, "")
+		})
+	}
+}
+
+func TestStringSliceVar(t *testing.T) {
+	newCmd := func() *command.Command {
+		var tags []string
+		return &command.Command{
+			Usage: "tag [--tag <tag>]...",
+			Short: "print tags",
+			Run: func(c *command.Command, args []string) error {
+				fmt.Fprintf(c.Stdout(), "%q\n", tags)
+				return nil
+			},
+			SetFlags: func(c *command.Command) {
+				c.StringSliceVar(&tags, "tag", []string{"default"}, "add a tag")
+			},
+		}
+	}
+
+	testExecute(t, newCmd(), nil, "", `["default"]`, "")
+	testExecute(t, newCmd(), []string{"--tag", "a"}, "", `["a"]`, "")
+	testExecute(t, newCmd(), []string{"--tag", "a", "--tag", "b"}, "", `["a" "b"]`, "")
+
+	app := newApp()
+	app.Add(newCmd())
+	testExecute(t, app, []string{"help", "tag"}, "", "Print tags\n\nUsage:\n\n    app tag [--tag <tag>]...\n\nFlags:\n\n    --tag value      add a tag (default [default])", "")
+}
+
+func TestFlagsInOrder(t *testing.T) {
+	tests := map[string]struct {
+		args []string
+		out  string
+	}{
+		"no flags": {
+			args: []string{"file"},
+			out:  "[] [file]",
+		},
+		"repeated flags": {
+			args: []string{"--add", "x", "--remove", "y", "-add=z", "file"},
+			out:  "[add remove add] [file]",
+		},
+		"bool flag": {
+			args: []string{"--add", "x", "-n", "--", "--remove"},
+			out:  "[add n] [--remove]",
+		},
+		"shorthands": {
+			args: []string{"-v", "-v", "--verbose", "-m", "x", "file"},
+			out:  "[verbose verbose verbose message] [file]",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := &command.Command{
+				Usage: "edit [--add <item>] [--remove <item>] [-n] [-v] [-m <message>] <file>",
+				Run: func(c *command.Command, args []string) error {
+					fmt.Fprintf(c.Stdout(), "%v %v\n", c.FlagsInOrder(), args)
+					return nil
+				},
+				SetFlags: func(c *command.Command) {
+					var add, remove []string
+					var dryRun, verbose bool
+					var msg string
+					c.StringSliceVar(&add, "add", nil, "add an item")
+					c.StringSliceVar(&remove, "remove", nil, "remove an item")
+					c.Flags().BoolVar(&dryRun, "n", false, "dry run")
+					c.BoolFlag(&verbose, "verbose", "v", false, "verbose output")
+					c.StringFlag(&msg, "message", "m", "", "edit message")
+				},
+			}
+			testExecute(t, c, test.args, "", test.out, "")
+		})
+	}
+}
+
+func TestFlagChanged(t *testing.T) {
+	tests := map[string]struct {
+		args []string
+		out  string
+	}{
+		"default": {
+			args: nil,
+			out:  "false world",
+		},
+		"default value": {
+			args: []string{"--message", "world"},
+			out:  "true world",
+		},
+		"shorthand": {
+			args: []string{"-m", "mundo"},
+			out:  "true mundo",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var msg string
+			c := &command.Command{
+				Usage: "hello [-m|--message <message>]",
+				Run: func(c *command.Command, args []string) error {
+					fmt.Fprintf(c.Stdout(), "%v %s\n", c.FlagChanged("message"), msg)
+					return nil
+				},
+				SetFlags: func(c *command.Command) {
+					c.StringFlag(&msg, "message", "m", "world", "greeting message")
+				},
+			}
+			testExecute(t, c, test.args, "", test.out, "")
+			if c.FlagChanged("undefined") {
+				t.Errorf("undefined flag: unexpected changed flag")
+			}
+		})
+	}
+
+	// flags with values that can not be compared
+	req := &command.Command{
+		Usage: "req [-v] [--header <key=value>]... [--query <key=value>]...",
+		Run: func(c *command.Command, args []string) error {
+			fmt.Fprintf(c.Stdout(), "%v %v %v\n", c.FlagChanged("header"), c.FlagChanged("query"), c.FlagChanged("v"))
+			return nil
+		},
+		SetFlags: func(c *command.Command) {
+			var verbose bool
+			c.BoolFlag(&verbose, "verbose", "v", false, "verbose output")
+			c.Flags().Var(kvValue{}, "header", "request header")
+			c.Flags().Var(kvValue{}, "query", "query parameter")
+		},
+	}
+	testExecute(t, req, []string{"--header", "a=b"}, "", "true false false", "")
+	testExecute(t, req, []string{"--verbose"}, "", "false false true", "")
+
+	if (&command.Command{Usage: "app"}).FlagChanged("message") {
+		t.Errorf("not executed: unexpected changed flag")
+	}
+}
+
+func TestDisableFlagParsing(t *testing.T) {
+	app := newApp()
+	app.Add(&command.Command{
+		Usage:              "run <program> [<argument>...]",
+		Short:              "run an external program",
+		DisableFlagParsing: true,
+		Run:                echoToStderrRun,
+		SetFlags: func(c *command.Command) {
+			c.Flags().Bool("verbose", false, "")
+		},
+	})
+
+	testExecute(t, app, []string{"run", "ls", "-l", "--help"}, "", "", "ls -l --help")
+	testExecute(t, app, []string{"run", "--verbose", "-h", "--", "x"}, "", "", "--verbose -h -- x")
+	testExecute(t, app, []string{"run", "-h"}, "", "", "-h")
+}
+
+func TestMarkFlagValues(t *testing.T) {
+	build := func() *command.Command {
+		app := &command.Command{
+			Usage: "app <command> [<argument>...]",
+		}
+		var format string
+		app.Add(&command.Command{
+			Usage: "list [-f|--format <format>]",
+			Short: "list items",
+			Run: func(c *command.Command, args []string) error {
+				fmt.Fprintf(c.Stdout(), "%s\n", format)
+				return nil
+			},
+			SetFlags: func(c *command.Command) {
+				c.StringFlag(&format, "format", "f", "text", "output format")
+				c.MarkFlagValues("format", "text", "json", "yaml")
+			},
+		})
+		return app
+	}
+
+	testExecute(t, build(), []string{"list"}, "", "text", "")
+	testExecute(t, build(), []string{"list", "--format", "json"}, "", "json", "")
+	testExecute(t, build(), []string{"list", "-f", "yaml"}, "", "yaml", "")
+	testExecuteError(t, build(), []string{"list", "--format", "xml"}, `app list: invalid value "xml" for flag --format: must be one of: text, json, yaml`)
+	testExecuteError(t, build(), []string{"list", "-f", "xml"}, `app list: invalid value "xml" for flag --format: must be one of: text, json, yaml`)
+
+	app := build()
+	app.HelpWidth = 100
+	testExecute(t, app, []string{"help", "list"}, "", "List items\n\nUsage:\n\n    app list [-f|--format <format>]\n\nFlags:\n\n    -f, --format string output format (default \"text\") (one of: text, json, yaml)", "")
+
+	testExecute(t, build(), []string{"__complete", "list", "--format", "j"}, "", "json\n:1", "")
+	testExecute(t, build(), []string{"__complete", "list", "-f", ""}, "", "text\njson\nyaml\n:1", "")
+}
+
+func TestOutputFormat(t *testing.T) {
+	newCmd := func() *command.Command {
+		format := command.NewOutputFormat("text", "json")
+		return &command.Command{
+			Usage: "list [--output <format>]",
+			Short: "list items",
+			Run: func(c *command.Command, args []string) error {
+				fmt.Fprintf(c.Stdout(), "%s\n", format.Value())
+				return nil
+			},
+			SetFlags: func(c *command.Command) {
+				c.Flags().Var(format, "output", "output `format`")
+			},
+		}
+	}
+
+	testExecute(t, newCmd(), nil, "", "text", "")
+	testExecute(t, newCmd(), []string{"--output", "json"}, "", "json", "")
+	testExecuteError(t, newCmd(), []string{"--output", "xml"}, `list: invalid value "xml" for flag -output: must be one of: text, json`)
+	testExecute(t, newCmd(), []string{"__complete", "--output", ""}, "", "text\njson\n:1", "")
+}
+
+func TestFlagNormalizeFunc(t *testing.T) {
+	newBuildApp := func() *command.Command {
+		app := &command.Command{
+			Usage: "app <command> [<argument>...]",
+			FlagNormalizeFunc: func(name string) string {
+				name = strings.ToLower(name)
+				return strings.NewReplacer("-", "", "_", "").Replace(name)
+			},
+		}
+		var dryRun bool
+		var outDir string
+		app.Add(&command.Command{
+			Usage: "build [--dry-run] [--out-dir <dir>] <package>",
+			Run: func(c *command.Command, args []string) error {
+				changed := c.FlagChanged("dry_run")
+				fmt.Fprintf(c.Stdout(), "%v %v %s %v\n", dryRun, changed, outDir, args)
+				return nil
+			},
+			SetFlags: func(c *command.Command) {
+				c.Flags().BoolVar(&dryRun, "dry-run", false, "")
+				c.Flags().StringVar(&outDir, "out-dir", ".", "")
+			},
+		})
+		return app
+	}
+
+	tests := map[string]struct {
+		args []string
+		out  string
+	}{
+		"canonical": {
+			args: []string{"build", "--dry-run", "--out-dir", "bin", "pkg"},
+			out:  "true true bin [pkg]",
+		},
+		"underscores": {
+			args: []string{"build", "--dry_run", "--out_dir=bin", "pkg"},
+			out:  "true true bin [pkg]",
+		},
+		"camel case": {
+			args: []string{"build", "-dryRun", "-outDir", "bin", "pkg"},
+			out:  "true true bin [pkg]",
+		},
+		"positional arguments": {
+			args: []string{"build", "--", "--dry_run"},
+			out:  "false false . [--dry_run]",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			testExecute(t, newBuildApp(), test.args, "", test.out, "")
+		})
+	}
+
+	testExecuteError(t, &command.Command{
+		Usage: "build",
+		Run:   func(c *command.Command, args []string) error { return nil },
+		SetFlags: func(c *command.Command) {
+			c.Flags().Bool("dry-run", false, "")
+		},
+	}, []string{"--dry_run"}, "build: flag provided but not defined: -dry_run")
+}
@@ -1,3 +1,5 @@
 module github.com/js-arias/command
 
-go 1.18
+go 1.21
+
+require golang.org/x/text v0.14.0
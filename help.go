@@ -0,0 +1,449 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+//
+// This work is derived from:
+//     * Go tool source code
+//       available at: https://cs.opensource.google/go/go.
+//	 Copyright 2011 The Go Authors.
+//     * Cobra source code
+//       available at: https://github.com/spf13/cobra.
+//       Copyright 2013 Steve Francia.
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Help prints the help of a command on w.
+func help(w io.Writer, c *Command) {
+	p := c.painter(w)
+	m := c.messages()
+	width := c.helpWidth(w)
+	fmt.Fprintf(w, "%s\n\n", p.bold(c.title(c.Short)))
+	if !c.isTopic() {
+		fmt.Fprintf(w, "%s\n\n    %s\n\n", p.bold(m.UsageHeader), c.longUsage())
+	}
+
+	if long := strings.TrimSpace(c.Long); long != "" {
+		fmt.Fprintf(w, "%s\n\n", wrapText(long, width))
+	}
+
+	fs, done := c.definedFlags()
+	defer done()
+	// a flag and its shorthands
+	// are in the same group
+	groups := make(map[string]string)
+	for _, g := range c.flagGroups {
+		for _, n := range g.flags {
+			n = c.flagName(n)
+			if _, ok := groups[n]; !ok {
+				groups[n] = g.name
+			}
+		}
+	}
+	helpFlags(w, p.bold(m.FlagsHeader), flagLines(fs, func(f *flag.Flag) bool {
+		if _, ok := groups[c.flagName(f.Name)]; ok {
+			return false
+		}
+		return !c.isInherited(f)
+	}, c.flagValues, c.shorthands), width)
+	for _, g := range c.flagGroups {
+		helpFlags(w, p.bold(g.name+":"), flagLines(fs, func(f *flag.Flag) bool {
+			return groups[c.flagName(f.Name)] == g.name && !c.isInherited(f)
+		}, c.flagValues, c.shorthands), width)
+	}
+	helpFlags(w, p.bold(m.GlobalFlagsHeader), flagLines(fs, c.isInherited, nil, c.shorthands), width)
+
+	if ex := strings.TrimSpace(c.Examples); ex != "" {
+		fmt.Fprintf(w, "%s\n\n", p.bold(m.ExamplesHeader))
+		for _, ln := range strings.Split(ex, "\n") {
+			ln = strings.TrimRight(ln, " \t")
+			if ln == "" {
+				fmt.Fprintf(w, "\n")
+				continue
+			}
+			fmt.Fprintf(w, "    %s\n", ln)
+		}
+		fmt.Fprintf(w, "\n")
+	}
+
+	if c.hasChildren() {
+		helpChildren(w, c)
+	}
+
+	if footer := strings.TrimSpace(c.HelpFooter); footer != "" {
+		fmt.Fprintf(w, "%s\n\n", footer)
+	}
+}
+
+// HelpString returns the help of the Command
+// as printed by the help command,
+// without colors.
+func (c *Command) HelpString() string {
+	var b strings.Builder
+	help(&b, c)
+	return b.String()
+}
+
+// UsageString returns the usage line of the Command
+// as printed after an usage error.
+// Help topics do not have an usage,
+// so it returns an empty string.
+func (c *Command) UsageString() string {
+	var b strings.Builder
+	c.usage(&b)
+	return b.String()
+}
+
+// PrintHelp prints the help of the Command,
+// as printed with the help flag.
+// The help of a Command with children
+// is printed on the standard error,
+// otherwise it is printed on the standard output.
+func (c *Command) PrintHelp() {
+	if c.hasChildren() {
+		help(c.Stderr(), c)
+		return
+	}
+	c.pagedHelp()
+}
+
+// PrintUsage prints the usage line of the Command
+// on the standard error.
+func (c *Command) PrintUsage() {
+	c.usage(c.Stderr())
+}
+
+// HelpAll prints the help of the Command
+// and all of its descendants on w,
+// separated by a rule line.
+// Hidden and deprecated commands
+// are ignored.
+func helpAll(w io.Writer, c *Command) {
+	rule := strings.Repeat("-", c.helpWidth(w))
+	first := true
+	c.Walk(func(cmd *Command) {
+		if !cmd.documented() || cmd.Deprecated != "" {
+			return
+		}
+		if !first {
+			fmt.Fprintf(w, "%s\n\n", rule)
+		}
+		first = false
+		help(w, cmd)
+	})
+}
+
+// HelpChildren prints the list of children commands
+// and help topics
+// of a command on w.
+func helpChildren(w io.Writer, c *Command) {
+	p := c.painter(w)
+	m := c.messages()
+	width := c.helpWidth(w)
+	children := c.children()
+	topics := false
+	groups := make(map[string][]*Command)
+	for _, n := range children {
+		cmd, ok := c.child(n)
+		if !ok {
+			continue
+		}
+		if cmd.Hidden || cmd.Deprecated != "" || !cmd.available() {
+			continue
+		}
+		if cmd.isTopic() {
+			topics = true
+			continue
+		}
+		groups[cmd.Group] = append(groups[cmd.Group], cmd)
+	}
+
+	printCmds := func(cmds []*Command) {
+		for _, cmd := range cmds {
+			fmt.Fprintf(w, "    %s %s\n", p.bold(cmd.name())+padding(cmd.name(), 16), wrapColumn(cmd.Short, 4+16+1, width))
+		}
+	}
+	if len(groups[""]) > 0 || len(groups) == 0 {
+		fmt.Fprintf(w, "%s\n\n", p.bold(m.CommandsHeader))
+		printCmds(groups[""])
+	}
+	for i, g := range c.groups(groups) {
+		if i > 0 || len(groups[""]) > 0 {
+			fmt.Fprintf(w, "\n")
+		}
+		fmt.Fprintf(w, "%s\n\n", p.bold(g+":"))
+		printCmds(groups[g])
+	}
+	hp := c.helpPath()
+	fmt.Fprintf(w, "\n"+m.CommandsHelp+"\n\n", hp+" <command>")
+
+	if !topics {
+		return
+	}
+	fmt.Fprintf(w, "%s\n\n", p.bold(m.TopicsHeader))
+	for _, n := range children {
+		t, ok := c.child(n)
+		if !ok {
+			continue
+		}
+		if t.Hidden || t.Deprecated != "" || !t.available() {
+			continue
+		}
+		if !t.isTopic() {
+			continue
+		}
+		fmt.Fprintf(w, "    %s %s\n", p.bold(t.name())+padding(t.name(), 16), wrapColumn(t.Short, 4+16+1, width))
+	}
+	fmt.Fprintf(w, "\n"+m.TopicsHelp+"\n\n", hp+" <topic>")
+}
+
+// Groups returns the names of the groups
+// of children commands,
+// in the order defined by GroupOrder,
+// followed by the other groups
+// in lexicographic order.
+func (c *Command) groups(groups map[string][]*Command) []string {
+	var names []string
+	done := make(map[string]bool)
+	for _, g := range c.GroupOrder {
+		if g == "" || done[g] || len(groups[g]) == 0 {
+			continue
+		}
+		done[g] = true
+		names = append(names, g)
+	}
+
+	var others []string
+	for g := range groups {
+		if g == "" || done[g] {
+			continue
+		}
+		others = append(others, g)
+	}
+	sort.Strings(others)
+	return append(names, others...)
+}
+
+// A flagLine is the help line of a flag.
+type flagLine struct {
+	names string
+	usage string
+}
+
+// FlagLines returns the help lines
+// of the flags with an usage message
+// in a flag set.
+// If keep is not nil,
+// only the flags for which keep returns true
+// are used.
+// A flag and its shorthands,
+// given as a map from the shorthand to the flag name,
+// are printed in the same line.
+// Values are the valid values of the flags.
+func flagLines(fs *flag.FlagSet, keep func(*flag.Flag) bool, values map[string][]string, shorthands map[string]string) []flagLine {
+	var order []string
+	kept := make(map[string]*flag.Flag)
+	fs.VisitAll(func(f *flag.Flag) {
+		if f.Usage == "" {
+			return
+		}
+		if keep != nil && !keep(f) {
+			return
+		}
+		kept[f.Name] = f
+		order = append(order, f.Name)
+	})
+
+	// use the long name as the main name
+	mainName := func(name string) string {
+		if long, ok := shorthands[name]; ok && kept[long] != nil {
+			return long
+		}
+		return name
+	}
+	shorts := make(map[string][]string)
+	for _, n := range order {
+		if m := mainName(n); m != n {
+			shorts[m] = append(shorts[m], "-"+n)
+		}
+	}
+
+	var lines []flagLine
+	done := make(map[string]bool)
+	for _, n := range order {
+		m := mainName(n)
+		if done[m] {
+			continue
+		}
+		done[m] = true
+		main := kept[m]
+
+		names := append([]string(nil), shorts[m]...)
+		name := "--" + main.Name
+		if len(main.Name) == 1 {
+			name = "-" + main.Name
+		}
+		names = append(names, name)
+
+		value, usage := flag.UnquoteUsage(main)
+		ln := strings.Join(names, ", ")
+		if value != "" {
+			ln += " " + value
+		}
+		if def := main.DefValue; def != "" && def != "0" && def != "false" && def != "[]" {
+			if value == "string" {
+				def = strconv.Quote(def)
+			}
+			usage += fmt.Sprintf(" (default %s)", def)
+		}
+		if v := values[main.Name]; len(v) > 0 {
+			usage += fmt.Sprintf(" (one of: %s)", strings.Join(v, ", "))
+		}
+		lines = append(lines, flagLine{names: ln, usage: usage})
+	}
+	return lines
+}
+
+// HelpFlags prints a section of flags
+// with the given header,
+// wrapping the usage of the flags
+// to the given width.
+func helpFlags(w io.Writer, header string, lines []flagLine, width int) {
+	if len(lines) == 0 {
+		return
+	}
+
+	col := 16
+	for _, ln := range lines {
+		if len(ln.names) > col {
+			col = len(ln.names)
+		}
+	}
+	fmt.Fprintf(w, "%s\n\n", header)
+	for _, ln := range lines {
+		fmt.Fprintf(w, "    %-*s %s\n", col, ln.names, wrapColumn(ln.usage, 4+col+1, width))
+	}
+	fmt.Fprintf(w, "\n")
+}
+
+// DefaultHelpWidth is the width of the help
+// if the width of the output
+// can not be determined.
+const defaultHelpWidth = 80
+
+// HelpWidth returns the width
+// used to print the help on w.
+func (c *Command) helpWidth(w io.Writer) int {
+	if r := c.root(); r.HelpWidth > 0 {
+		return r.HelpWidth
+	}
+	f, ok := w.(*os.File)
+	if !ok || !isTerminal(f) {
+		return defaultHelpWidth
+	}
+	if cols, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && cols > 0 {
+		return cols
+	}
+	if cols := termWidth(f); cols > 0 {
+		return cols
+	}
+	return defaultHelpWidth
+}
+
+// WrapText wraps the lines of a text
+// longer than width.
+// The wrapped lines keep the indentation
+// of the original line.
+func wrapText(text string, width int) string {
+	lines := strings.Split(text, "\n")
+	for i, ln := range lines {
+		if utf8.RuneCountInString(ln) <= width {
+			continue
+		}
+		indent := ln[:len(ln)-len(strings.TrimLeft(ln, " \t"))]
+		lines[i] = indent + strings.Join(wrapWords(ln, width-len(indent)), "\n"+indent)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// WrapColumn wraps a text
+// printed in a column
+// that starts at position col,
+// so the text fits in the given width.
+// The wrapped lines are indented
+// up to the column.
+func wrapColumn(text string, col, width int) string {
+	if col+utf8.RuneCountInString(text) <= width {
+		return text
+	}
+	return strings.Join(wrapWords(text, width-col), "\n"+strings.Repeat(" ", col))
+}
+
+// WrapWords splits the words of a text
+// in lines of the given width.
+// Words longer than width
+// are not split.
+func wrapWords(text string, width int) []string {
+	var lines []string
+	var cur string
+	for _, w := range strings.Fields(text) {
+		if cur == "" {
+			cur = w
+			continue
+		}
+		if utf8.RuneCountInString(cur)+1+utf8.RuneCountInString(w) > width {
+			lines = append(lines, cur)
+			cur = w
+			continue
+		}
+		cur += " " + w
+	}
+	if cur != "" {
+		lines = append(lines, cur)
+	}
+	return lines
+}
+
+// Padding returns the spaces required
+// to fill s up to the given width.
+func padding(s string, width int) string {
+	n := width - utf8.RuneCountInString(s)
+	if n <= 0 {
+		return ""
+	}
+	return strings.Repeat(" ", n)
+}
+
+// Title formats s as a title
+// using the TitleFunc of the Command
+// or its ancestors.
+func (c *Command) title(s string) string {
+	for p := c; p != nil; p = p.parent {
+		if p.TitleFunc != nil {
+			return p.TitleFunc(s)
+		}
+	}
+	return toTitle(s)
+}
+
+// ToTitle changes the first letter of s
+// to title case.
+func toTitle(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if s == "" {
+		return ""
+	}
+	r, i := utf8.DecodeRuneInString(s)
+	return string(unicode.ToTitle(r)) + s[i:]
+}
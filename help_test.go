@@ -13,7 +13,15 @@
 package command_test
 
 import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
+
+	"github.com/js-arias/command"
 )
 
 var appHelp = `App is an app for testing
@@ -57,7 +65,12 @@ Usage:
     app hello [--utf8] [--message <message>]
 
 Command hello prints the well known "hello, world" message, or if --message
-flag is defined, a personalized hello message.`
+flag is defined, a personalized hello message.
+
+Flags:
+
+    --message string sets the greeting message (default "world")
+    --utf8           print an utf8 message`
 
 var catHelp = `Print stdin
 
@@ -134,6 +147,14 @@ func TestExecuteHelp(t *testing.T) {
 	}
 }
 
+func TestHelpOnStdout(t *testing.T) {
+	app := newApp()
+	app.HelpOnStdout = true
+	testExecute(t, app, nil, "", appHelp, "")
+	testExecute(t, app, []string{"cmd"}, "", cmdHelp, "")
+	testExecute(t, app, []string{"cmd", "-h"}, "", "", cmdHelp)
+}
+
 func TestHelpError(t *testing.T) {
 	tests := map[string]struct {
 		args   []string
@@ -141,19 +162,23 @@ func TestHelpError(t *testing.T) {
 	}{
 		"unknown help topic on root": {
 			args:   []string{"help", "unknown"},
-			errMsg: `app help unknown: unknown help topic. Run "app help"`,
+			errMsg: `app help unknown: unknown help topic. Run "app help". Valid topics: cmd, error, hello, topic`,
 		},
 		"unknown help topic on children": {
 			args:   []string{"cmd", "help", "unknown"},
-			errMsg: `app help cmd unknown: unknown help topic. Run "app help cmd"`,
+			errMsg: `app help cmd unknown: unknown help topic. Run "app help cmd". Valid topics: cat, echo, error`,
 		},
 		"unknown help topic (on sub-command": {
 			args:   []string{"help", "cmd", "unknown"},
-			errMsg: `app help cmd unknown: unknown help topic. Run "app help cmd"`,
+			errMsg: `app help cmd unknown: unknown help topic. Run "app help cmd". Valid topics: cat, echo, error`,
 		},
 		"unknown help topic (multiple arguments)": {
 			args:   []string{"help", "unknown", "command"},
-			errMsg: `app help unknown command: unknown help topic. Run "app help"`,
+			errMsg: `app help unknown command: unknown help topic. Run "app help". Valid topics: cmd, error, hello, topic`,
+		},
+		"unknown help topic with suggestion": {
+			args:   []string{"help", "topik"},
+			errMsg: `app help topik: unknown help topic. Run "app help". Valid topics: cmd, error, hello, topic. Did you mean "topic"?`,
 		},
 		" extra arguments in a children command": {
 			args:   []string{"help", "hello", "unknown"},
@@ -168,3 +193,620 @@ func TestHelpError(t *testing.T) {
 		})
 	}
 }
+
+var debugHelp = `Print debug information
+
+Usage:
+
+    app debug`
+
+func TestHiddenCommand(t *testing.T) {
+	app := newApp()
+	app.Add(&command.Command{
+		Usage:  "debug",
+		Short:  "print debug information",
+		Run:    echoToStderrRun,
+		Hidden: true,
+	})
+
+	testExecute(t, app, []string{"help"}, "", appHelp, "")
+	testExecute(t, app, []string{"debug", "data"}, "", "", "data")
+	testExecute(t, app, []string{"help", "debug"}, "", debugHelp, "")
+}
+
+func TestDeprecatedCommand(t *testing.T) {
+	app := newApp()
+	app.Add(&command.Command{
+		Usage:      "fetch",
+		Short:      "fetch data",
+		Run:        echoToStderrRun,
+		Deprecated: `use "pull" instead`,
+	})
+
+	testExecute(t, app, []string{"help"}, "", appHelp, "")
+	testExecute(t, app, []string{"fetch", "data"}, "", "", "Command \"fetch\" is deprecated: use \"pull\" instead\ndata")
+}
+
+var examplesHelp = `Print its arguments
+
+Usage:
+
+    app say <argument>...
+
+Command say prints its arguments.
+
+Examples:
+
+    $ app say hello
+    hello
+
+    $ app say hello world
+    hello world`
+
+func TestHelpExamples(t *testing.T) {
+	app := newApp()
+	app.Add(&command.Command{
+		Usage: "say <argument>...",
+		Short: "print its arguments",
+		Long:  "Command say prints its arguments.",
+		Examples: `
+$ app say hello
+hello
+
+$ app say hello world
+hello world
+		`,
+		Run: echoToStderrRun,
+	})
+
+	testExecute(t, app, []string{"help", "say"}, "", examplesHelp, "")
+}
+
+func TestHelpFooter(t *testing.T) {
+	app := newApp()
+	app.HelpFooter = "See https://example.com/app for the full documentation."
+	testExecute(t, app, []string{"help"}, "", appHelp+"\n\n"+app.HelpFooter, "")
+
+	app = newApp()
+	app.Add(&command.Command{
+		Usage:      "report",
+		Short:      "report a bug",
+		HelpFooter: "Contact: support@example.com",
+		Run:        echoToStderrRun,
+	})
+	footerHelp := `Report a bug
+
+Usage:
+
+    app report
+
+Contact: support@example.com`
+	testExecute(t, app, []string{"help", "report"}, "", footerHelp, "")
+}
+
+var gatedAppHelp = `App is an app for testing
+
+Usage:
+
+    app <command> [<argument>...]
+
+The commands are:
+
+    cmd              a collection of commands
+    error            always return an error
+
+Use "app help <command>" for more information about a command.
+
+Additional help topics:
+
+    topic            a help topic
+
+Use "app help <topic>" for more information about that topic.`
+
+func TestFeatureGate(t *testing.T) {
+	app := newApp()
+	calls := 0
+	app.FeatureGate = func(c *command.Command) bool {
+		calls++
+		return c.Short != "print a hello message"
+	}
+
+	testExecute(t, app, []string{"help"}, "", gatedAppHelp, "")
+	testExecute(t, app, []string{"cmd", "echo", "data"}, "", "", "data")
+	testExecuteError(t, app, []string{"hello"}, "app hello: not available in your edition")
+	testExecuteError(t, app, []string{"help", "hello"}, "app hello: not available in your edition")
+
+	calls = 0
+	testExecute(t, app, []string{"help"}, "", gatedAppHelp, "")
+	if calls != 4 {
+		t.Errorf("feature gate: got %d calls, want 4", calls)
+	}
+}
+
+func TestUsageFunc(t *testing.T) {
+	app := newApp()
+	app.UsageFunc = func(c *command.Command) string {
+		return "Usage of " + c.Usage
+	}
+	testExecute(t, app, []string{"hello", "-h"}, "", "", "Usage of hello [--utf8] [--message <message>]")
+	testExecute(t, app, []string{"cmd", "cat", "-h"}, "", "", "Usage of cat")
+}
+
+func TestTitleFunc(t *testing.T) {
+	app := newApp()
+	app.TitleFunc = func(s string) string { return s }
+	testExecute(t, app, []string{"help", "topic"}, "", "a help topic\n\nA help topic is a non-runnable command used only for documentation.", "")
+	testExecute(t, app, []string{"help", "cmd", "cat"}, "", "print stdin\n\nUsage:\n\n    app cmd cat\n\nCommand cat is used to print the content of the stdin into the stdout.", "")
+}
+
+func TestMessages(t *testing.T) {
+	app := newApp()
+	app.Messages = &command.Messages{
+		UnknownCommand: "comando desconocido",
+		Usage:          "uso: %s",
+		UsageHeader:    "Uso:",
+		CommandsHeader: "Los comandos son:",
+		CommandsHelp:   "Use %q para más información sobre un comando.",
+	}
+
+	testExecute(t, app, []string{"hello", "-h"}, "", "", "uso: app hello [--utf8] [--message <message>]")
+	testExecuteError(t, app, []string{"unknown"}, "app unknown: comando desconocido")
+
+	var b strings.Builder
+	app.SetStderr(&b)
+	if err := app.Execute([]string{"cmd"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{
+		"Uso:\n\n    app cmd <command> [<argument>...]\n",
+		"Los comandos son:\n",
+		`Use "app help cmd <command>" para más información sobre un comando.`,
+	}
+	for _, w := range want {
+		if !strings.Contains(b.String(), w) {
+			t.Errorf("help: expecting %q in:\n%s", w, b.String())
+		}
+	}
+}
+
+func TestHelpString(t *testing.T) {
+	app := newApp()
+	if got := strings.TrimSpace(app.HelpString()); got != appHelp {
+		t.Errorf("help: got %q, want %q", got, appHelp)
+	}
+	if got, want := app.UsageString(), "usage: app <command> [<argument>...]\n"; got != want {
+		t.Errorf("usage: got %q, want %q", got, want)
+	}
+
+	topic := &command.Command{
+		Usage: "topic",
+		Short: "a help topic",
+	}
+	if got, want := topic.HelpString(), "A help topic\n\n"; got != want {
+		t.Errorf("topic help: got %q, want %q", got, want)
+	}
+	if got := topic.UsageString(); got != "" {
+		t.Errorf("topic usage: got %q, want an empty string", got)
+	}
+}
+
+func TestParentUsage(t *testing.T) {
+	app := newApp()
+	var errOut strings.Builder
+	app.SetStderr(&errOut)
+
+	// a parent without Run has an usage
+	var cmd *command.Command
+	app.Walk(func(c *command.Command) {
+		if strings.HasPrefix(c.Usage, "cmd ") {
+			cmd = c
+		}
+	})
+	if got, want := cmd.UsageString(), "usage: app cmd <command> [<argument>...]\n"; got != want {
+		t.Errorf("parent usage: got %q, want %q", got, want)
+	}
+	cmd.PrintUsage()
+	if got, want := errOut.String(), "usage: app cmd <command> [<argument>...]\n"; got != want {
+		t.Errorf("printed parent usage: got %q, want %q", got, want)
+	}
+}
+
+func TestPrintHelp(t *testing.T) {
+	app := &command.Command{
+		Usage: "app <command> [<argument>...]",
+		Short: "an application",
+	}
+	child := &command.Command{
+		Usage: "say <message>",
+		Short: "print a message",
+		Run:   func(c *command.Command, args []string) error { return nil },
+	}
+	app.Add(child)
+
+	var out, errOut bytes.Buffer
+	app.SetStdout(&out)
+	app.SetStderr(&errOut)
+
+	child.PrintHelp()
+	if got, want := out.String(), "Print a message\n\nUsage:\n\n    app say <message>\n\n"; got != want {
+		t.Errorf("child help: got %q, want %q", got, want)
+	}
+	if errOut.Len() > 0 {
+		t.Errorf("child help: unexpected stderr %q", errOut.String())
+	}
+
+	out.Reset()
+	app.PrintHelp()
+	if out.Len() > 0 {
+		t.Errorf("parent help: unexpected stdout %q", out.String())
+	}
+	if got := errOut.String(); got != app.HelpString() {
+		t.Errorf("parent help: got %q, want %q", got, app.HelpString())
+	}
+
+	errOut.Reset()
+	child.PrintUsage()
+	if got, want := errOut.String(), "usage: app say <message>\n"; got != want {
+		t.Errorf("usage: got %q, want %q", got, want)
+	}
+}
+
+func TestHelpAll(t *testing.T) {
+	app := newApp()
+	app.Add(&command.Command{
+		Usage:  "secret",
+		Short:  "a secret command",
+		Run:    echoToStderrRun,
+		Hidden: true,
+	})
+
+	var b strings.Builder
+	app.SetStdout(&b)
+	if err := app.Execute([]string{"--help-all"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := b.String()
+	if !strings.HasPrefix(got, appHelp) {
+		t.Errorf("help all: expecting the root help at the beginning:\n%s", got)
+	}
+	rule := strings.Repeat("-", 80) + "\n\n"
+	want := []string{
+		rule + "A collection of commands\n",
+		rule + "Print stdin\n",
+		rule + "A help topic\n",
+	}
+	for _, w := range want {
+		if !strings.Contains(got, w) {
+			t.Errorf("help all: expecting %q in:\n%s", w, got)
+		}
+	}
+	if strings.Contains(got, "secret") {
+		t.Errorf("help all: hidden commands should not be printed:\n%s", got)
+	}
+
+	// the flag is not listed
+	if strings.Contains(app.HelpString(), "help-all") {
+		t.Errorf("help: unexpected help-all flag:\n%s", app.HelpString())
+	}
+}
+
+var shortHelp = `Print messages
+
+Usage:
+
+    app say [-v] [-n <number>] [--message <message>]
+
+Flags:
+
+    -m, --message string print this message (default "hello")
+    -n int               repeat the message n times (default 1)
+    -v, --verbose        print a verbose output`
+
+func TestFlagShorthand(t *testing.T) {
+	app := newApp()
+	var verbose bool
+	var n int
+	var msg string
+	app.Add(&command.Command{
+		Usage: "say [-v] [-n <number>] [--message <message>]",
+		Short: "print messages",
+		Run: func(c *command.Command, args []string) error {
+			for i := 0; i < n; i++ {
+				fmt.Fprintf(c.Stdout(), "%s %v\n", msg, verbose)
+			}
+			return nil
+		},
+		SetFlags: func(c *command.Command) {
+			c.BoolFlag(&verbose, "verbose", "v", false, "print a verbose output")
+			c.IntFlag(&n, "n", "", 1, "repeat the message n times")
+			c.StringFlag(&msg, "message", "m", "hello", "print this message")
+		},
+	})
+
+	testExecute(t, app, []string{"say", "-v", "-m", "bye"}, "", "bye true", "")
+	testExecute(t, app, []string{"say", "--verbose", "--message", "bye", "-n", "2"}, "", "bye true\nbye true", "")
+	testExecute(t, app, []string{"help", "say"}, "", shortHelp, "")
+}
+
+var flagGroupHelp = `Connect to a server
+
+Usage:
+
+    app connect [flags] <server>
+
+Flags:
+
+    --verbose        print a verbose output
+
+Connection flags:
+
+    --header value   request header
+    -p, --port int   server port (default 80)
+    --timeout int    timeout in seconds (default 10)
+
+Output flags:
+
+    --json           print the output in JSON`
+
+func TestFlagGroup(t *testing.T) {
+	app := newApp()
+	app.Add(&command.Command{
+		Usage: "connect [flags] <server>",
+		Short: "connect to a server",
+		Run:   func(c *command.Command, args []string) error { return nil },
+		SetFlags: func(c *command.Command) {
+			var port, timeout int
+			var verbose, json bool
+			c.IntFlag(&port, "port", "p", 80, "server port")
+			c.Flags().BoolVar(&verbose, "verbose", false, "print a verbose output")
+			c.Flags().IntVar(&timeout, "timeout", 10, "timeout in seconds")
+			c.Flags().BoolVar(&json, "json", false, "print the output in JSON")
+			c.Flags().Var(kvValue{}, "header", "request header")
+			c.FlagGroup("Connection flags", "p", "header")
+			c.FlagGroup("Output flags", "json")
+			c.FlagGroup("Connection flags", "timeout")
+		},
+	})
+
+	testExecute(t, app, []string{"help", "connect"}, "", flagGroupHelp, "")
+}
+
+// A kvValue is a flag value
+// of a map type,
+// that can not be compared.
+type kvValue map[string]string
+
+func (kv kvValue) String() string {
+	var pairs []string
+	for k, v := range kv {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (kv kvValue) Set(s string) error {
+	k, v, _ := strings.Cut(s, "=")
+	kv[k] = v
+	return nil
+}
+
+var mapFlagHelp = `Send a request
+
+Usage:
+
+    app req [--header <key=value>]...
+
+Flags:
+
+    --header value   request header
+    --query value    query parameter`
+
+func TestHelpMapFlag(t *testing.T) {
+	app := newApp()
+	app.Add(&command.Command{
+		Usage: "req [--header <key=value>]...",
+		Short: "send a request",
+		Run:   func(c *command.Command, args []string) error { return nil },
+		SetFlags: func(c *command.Command) {
+			c.Flags().Var(kvValue{}, "header", "request header")
+			c.Flags().Var(kvValue{}, "query", "query parameter")
+		},
+	})
+
+	testExecute(t, app, []string{"help", "req"}, "", mapFlagHelp, "")
+}
+
+var configHelp = `Configuration of the application
+
+The application is configured using a configuration file.`
+
+func TestHelpTopicAlias(t *testing.T) {
+	app := newApp()
+	app.Add(&command.Command{
+		Usage:   "configuration",
+		Aliases: []string{"config", "cfg"},
+		Short:   "configuration of the application",
+		Long:    "The application is configured using a configuration file.",
+	})
+
+	for _, name := range []string{"configuration", "config", "CFG"} {
+		testExecute(t, app, []string{"help", name}, "", configHelp, "")
+		testExecute(t, app, []string{name, "-h"}, "", configHelp, "")
+	}
+	testExecuteError(t, app, []string{"help", "config", "unknown"}, `app help configuration unknown: unknown help topic. Run "app help configuration"`)
+}
+
+func TestHelpPathAlias(t *testing.T) {
+	app := newApp()
+	files := &command.Command{
+		Usage:   "files <command> [<argument>...]",
+		Aliases: []string{"f"},
+		Short:   "manage files",
+	}
+	app.Add(files)
+	files.Add(&command.Command{
+		Usage:   "remove <file>",
+		Aliases: []string{"rm"},
+		Short:   "remove a file",
+		Run: func(c *command.Command, args []string) error {
+			if len(args) == 0 {
+				return c.UsageError("expecting file")
+			}
+			return nil
+		},
+	})
+
+	testExecute(t, app, []string{"f", "rm", "-h"}, "", "", "usage: app files remove <file>")
+	testExecuteError(t, app, []string{"F", "RM"}, "app files remove: expecting file")
+
+	var b strings.Builder
+	app.SetStderr(&b)
+	if err := app.Execute([]string{"f", "-h"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `Use "app help files <command>"`; !strings.Contains(b.String(), want) {
+		t.Errorf("help: expecting %q in:\n%s", want, b.String())
+	}
+}
+
+func TestHelpNoColor(t *testing.T) {
+	// colors are only used in terminals
+	f, err := os.Create(filepath.Join(t.TempDir(), "help.txt"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer f.Close()
+
+	app := newApp()
+	app.SetStdout(f)
+	if err := app.Execute([]string{"help"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.TrimSpace(string(b)); got != appHelp {
+		t.Errorf("help:\n%q\nwant:\n%q", got, appHelp)
+	}
+}
+
+var narrowHelp = `Print a long message
+
+Usage:
+
+    app long [--verbose] [--message <message>] <argument>...
+
+Command long prints a long message
+that is wrapped
+    when the line is longer than the
+    width of the help.
+
+Flags:
+
+    --verbose        print a lot of
+                     information on the
+                     screen`
+
+func TestHelpWidth(t *testing.T) {
+	app := newApp()
+	app.HelpWidth = 40
+	app.Add(&command.Command{
+		Usage: "long [--verbose] [--message <message>] <argument>...",
+		Short: "print a long message",
+		Long: `
+Command long prints a long message
+that is wrapped
+    when the line is longer than the width of the help.
+		`,
+		Run: echoToStderrRun,
+		SetFlags: func(c *command.Command) {
+			c.Flags().Bool("verbose", false, "print a lot of information on the screen")
+		},
+	})
+
+	testExecute(t, app, []string{"help", "long"}, "", narrowHelp, "")
+}
+
+func TestUsePager(t *testing.T) {
+	t.Setenv("PAGER", "a-pager-that-does-not-exist")
+
+	// the pager is only used in terminals
+	app := newApp()
+	app.UsePager = true
+	testExecute(t, app, []string{"help"}, "", appHelp, "")
+}
+
+func TestHelpCommandName(t *testing.T) {
+	app := newApp()
+	app.HelpCommandName = "ayuda"
+
+	want := strings.ReplaceAll(cmdHelp, "app help cmd", "app ayuda cmd")
+	testExecute(t, app, []string{"ayuda", "cmd"}, "", want, "")
+	testExecute(t, app, []string{"cmd", "ayuda"}, "", want, "")
+	testExecuteError(t, app, []string{"ayuda", "unknown"}, `app ayuda unknown: unknown help topic. Run "app ayuda". Valid topics: cmd, error, hello, topic`)
+	testExecuteError(t, app, []string{"help"}, `app help: unknown command. Did you mean "hello"?`)
+}
+
+var groupHelp = `App is an app for testing
+
+Usage:
+
+    app <command> [<argument>...]
+
+The commands are:
+
+    cmd              a collection of commands
+    error            always return an error
+    hello            print a hello message
+
+Write Commands:
+
+    rm               remove an item
+
+Read Commands:
+
+    get              get an item
+    list             list items
+
+Use "app help <command>" for more information about a command.
+
+Additional help topics:
+
+    topic            a help topic
+
+Use "app help <topic>" for more information about that topic.`
+
+func TestHelpGroups(t *testing.T) {
+	app := newApp()
+	app.GroupOrder = []string{"Write Commands"}
+	app.Add(&command.Command{Usage: "list", Short: "list items", Run: echoToStderrRun, Group: "Read Commands"})
+	app.Add(&command.Command{Usage: "get", Short: "get an item", Run: echoToStderrRun, Group: "Read Commands"})
+	app.Add(&command.Command{Usage: "rm", Short: "remove an item", Run: echoToStderrRun, Group: "Write Commands"})
+
+	testExecute(t, app, []string{"help"}, "", groupHelp, "")
+}
+
+func TestDisableAutoHelp(t *testing.T) {
+	newNoHelpApp := func() *command.Command {
+		app := newApp()
+		app.DisableAutoHelp = true
+		return app
+	}
+
+	testExecuteError(t, newNoHelpApp(), []string{"help"}, `app help: unknown command. Did you mean "hello"?`)
+	testExecuteError(t, newNoHelpApp(), []string{"hello", "-h"}, "app hello: flag provided but not defined: -h")
+	testExecuteError(t, newNoHelpApp(), []string{"--help"}, "app: flag provided but not defined: --help")
+
+	app := newNoHelpApp()
+	app.Add(&command.Command{
+		Usage: "help [<topic>]",
+		Short: "custom help",
+		Run: func(c *command.Command, args []string) error {
+			fmt.Fprintf(c.Stdout(), "custom help: %v\n", args)
+			return nil
+		},
+	})
+	testExecute(t, app, []string{"help", "hello"}, "", "custom help: [hello]", "")
+}
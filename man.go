@@ -0,0 +1,144 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+//
+// This work is derived from:
+//     * Cobra source code
+//       available at: https://github.com/spf13/cobra.
+//       Copyright 2013 Steve Francia.
+
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// A ManHeader is the header
+// of the generated man pages.
+type ManHeader struct {
+	// Section of the manual.
+	// By default is "1".
+	Section string
+
+	// Date of the man pages.
+	// By default is the current date.
+	Date time.Time
+
+	// Source of the command,
+	// for example the application name and version.
+	Source string
+
+	// Manual is the title of the manual.
+	Manual string
+}
+
+// GenManTree writes a man page
+// for each runnable Command
+// in the tree of commands
+// into the directory dir.
+// The name of each file
+// is the long name of the Command
+// joined by dashes,
+// for example "app-cmd-echo.1".
+// Hidden commands,
+// and its descendants,
+// are ignored.
+func (c *Command) GenManTree(dir string, header ManHeader) error {
+	if header.Section == "" {
+		header.Section = "1"
+	}
+	if header.Date.IsZero() {
+		header.Date = time.Now()
+	}
+
+	var err error
+	c.Walk(func(cmd *Command) {
+		if err != nil {
+			return
+		}
+		if cmd.Run == nil || !cmd.documented() {
+			return
+		}
+		name := strings.ReplaceAll(cmd.longName(), " ", "-")
+		file := filepath.Join(dir, name+"."+header.Section)
+		err = os.WriteFile(file, []byte(cmd.manPage(name, header)), 0644)
+	})
+	return err
+}
+
+// ManPage returns the man page of a Command.
+func (c *Command) manPage(name string, header ManHeader) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, ".TH %s %s %s %s %s\n", manQuote(strings.ToUpper(name)), manQuote(header.Section), manQuote(header.Date.Format("Jan 2006")), manQuote(header.Source), manQuote(header.Manual))
+	fmt.Fprintf(&b, ".nh\n.ad l\n")
+
+	fmt.Fprintf(&b, ".SH NAME\n")
+	fmt.Fprintf(&b, "%s \\- %s\n", manEscape(name), manEscape(strings.Join(strings.Fields(c.Short), " ")))
+
+	fmt.Fprintf(&b, ".SH SYNOPSIS\n")
+	fmt.Fprintf(&b, ".B %s\n", manEscape(c.longName()))
+	if f := strings.Fields(c.Usage); len(f) > 1 {
+		fmt.Fprintf(&b, "%s\n", manEscape(strings.Join(f[1:], " ")))
+	}
+
+	desc := strings.TrimSpace(c.Long)
+	if desc == "" {
+		desc = c.title(c.Short)
+	}
+	if desc != "" {
+		fmt.Fprintf(&b, ".SH DESCRIPTION\n")
+		for i, p := range strings.Split(desc, "\n\n") {
+			p = strings.TrimSpace(p)
+			if p == "" {
+				continue
+			}
+			if i > 0 {
+				fmt.Fprintf(&b, ".PP\n")
+			}
+			fmt.Fprintf(&b, "%s\n", manEscape(strings.Join(strings.Fields(p), " ")))
+		}
+	}
+
+	fs, done := c.definedFlags()
+	defer done()
+	if lines := flagLines(fs, nil, c.flagValues, c.shorthands); len(lines) > 0 {
+		fmt.Fprintf(&b, ".SH OPTIONS\n")
+		for _, ln := range lines {
+			fmt.Fprintf(&b, ".TP\n.B %s\n%s\n", manEscape(ln.names), manEscape(ln.usage))
+		}
+	}
+
+	if ex := strings.TrimSpace(c.Examples); ex != "" {
+		fmt.Fprintf(&b, ".SH EXAMPLES\n.PP\n.RS\n.nf\n")
+		fmt.Fprintf(&b, "%s\n", manEscape(ex))
+		fmt.Fprintf(&b, ".fi\n.RE\n")
+	}
+
+	return b.String()
+}
+
+// ManEscape escapes a text
+// to be used in a man page.
+func manEscape(s string) string {
+	s = strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(s)
+	lines := strings.Split(s, "\n")
+	for i, ln := range lines {
+		if strings.HasPrefix(ln, ".") || strings.HasPrefix(ln, "'") {
+			lines[i] = `\&` + ln
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ManQuote returns s as a quoted argument
+// of a roff request.
+func manQuote(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	s = strings.NewReplacer(`\`, `\e`, `"`, `\(dq`).Replace(s)
+	return `"` + s + `"`
+}
@@ -0,0 +1,144 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package command_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/js-arias/command"
+)
+
+var helloMan = `.TH "APP-HELLO" "8" "Mar 2022" "app 1.0" "App Manual"
+.nh
+.ad l
+.SH NAME
+app\-hello \- print a hello message
+.SH SYNOPSIS
+.B app hello
+[\-\-utf8] [\-\-message <message>]
+.SH DESCRIPTION
+Command hello prints the well known "hello, world" message, or if \-\-message flag is defined, a personalized hello message.
+.SH OPTIONS
+.TP
+.B \-\-message string
+sets the greeting message (default "world")
+.TP
+.B \-\-utf8
+print an utf8 message
+`
+
+func TestGenManTree(t *testing.T) {
+	dir := t.TempDir()
+	app := newApp()
+	header := command.ManHeader{
+		Section: "8",
+		Date:    time.Date(2022, time.March, 1, 0, 0, 0, 0, time.UTC),
+		Source:  "app 1.0",
+		Manual:  "App Manual",
+	}
+	if err := app.GenManTree(dir, header); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, filepath.Base(f))
+	}
+	want := "app-cmd-cat.8 app-cmd-echo.8 app-cmd-error.8 app-error.8 app-hello.8"
+	if got := strings.Join(names, " "); got != want {
+		t.Errorf("files: got %q, want %q", got, want)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "app-hello.8"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := string(b); got != helloMan {
+		t.Errorf("man page:\n%s\nwant:\n%s", got, helloMan)
+	}
+}
+
+func TestGenManTreeHidden(t *testing.T) {
+	dir := t.TempDir()
+	app := &command.Command{
+		Usage: "app <command> [<argument>...]",
+	}
+	tools := &command.Command{
+		Usage:  "tools <command> [<argument>...]",
+		Hidden: true,
+	}
+	app.Add(tools)
+	tools.Add(&command.Command{
+		Usage: "run",
+		Short: "run a tool",
+		Run:   func(c *command.Command, args []string) error { return nil },
+	})
+	app.Add(&command.Command{
+		Usage: "hello",
+		Short: "print a greeting",
+		Run:   func(c *command.Command, args []string) error { return nil },
+	})
+
+	header := command.ManHeader{
+		Date:   time.Date(2022, time.March, 1, 0, 0, 0, 0, time.UTC),
+		Source: `app "beta"`,
+		Manual: "App\nManual",
+	}
+	if err := app.GenManTree(dir, header); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(files) != 1 || filepath.Base(files[0]) != "app-hello.1" {
+		t.Fatalf("files: got %v, want [app-hello.1]", files)
+	}
+
+	b, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `.TH "APP-HELLO" "1" "Mar 2022" "app \(dqbeta\(dq" "App Manual"`
+	if got, _, _ := strings.Cut(string(b), "\n"); got != want {
+		t.Errorf("title: got %q, want %q", got, want)
+	}
+}
+
+func TestGenManTreeFromRun(t *testing.T) {
+	dir := t.TempDir()
+	app := newApp()
+	var verbose bool
+	app.SetFlags = func(c *command.Command) {
+		c.PersistentFlags().BoolVar(&verbose, "verbose", false, "print more information")
+	}
+	var gen string
+	app.Add(&command.Command{
+		Usage: "docs [--gen <format>]",
+		Short: "generate the documentation",
+		Run: func(c *command.Command, args []string) error {
+			if err := c.Root().GenManTree(dir, command.ManHeader{}); err != nil {
+				return err
+			}
+			fmt.Fprintf(c.Stdout(), "gen: %s verbose: %v\n", gen, verbose)
+			return nil
+		},
+		SetFlags: func(c *command.Command) {
+			c.Flags().StringVar(&gen, "gen", "md", "documentation format")
+		},
+	})
+
+	testExecute(t, app, []string{"--verbose", "docs", "--gen", "man"}, "", "gen: man verbose: true", "")
+}
@@ -0,0 +1,134 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+//
+// This work is derived from:
+//     * Cobra source code
+//       available at: https://github.com/spf13/cobra.
+//       Copyright 2013 Steve Francia.
+
+package command
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GenMarkdownTree writes a Markdown document
+// for each Command
+// in the tree of commands
+// into the directory dir.
+// The name of each file
+// is the long name of the Command
+// joined by dashes,
+// for example "app-cmd-echo.md".
+// It also writes an "index.md" file
+// with the list of all commands.
+// Hidden commands are ignored.
+func (c *Command) GenMarkdownTree(dir string) error {
+	var index strings.Builder
+	fmt.Fprintf(&index, "# %s\n\n", c.longName())
+	if short := c.title(c.Short); short != "" {
+		fmt.Fprintf(&index, "%s\n\n", short)
+	}
+
+	var err error
+	c.Walk(func(cmd *Command) {
+		if err != nil {
+			return
+		}
+		if !cmd.documented() {
+			return
+		}
+		fmt.Fprintf(&index, "- [%s](%s): %s\n", cmd.longName(), cmd.markdownFile(), cmd.Short)
+		err = os.WriteFile(filepath.Join(dir, cmd.markdownFile()), []byte(cmd.markdown()), 0644)
+	})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "index.md"), []byte(index.String()), 0644)
+}
+
+// Documented returns true if the Command
+// and its ancestors are not hidden
+// and are available.
+func (c *Command) documented() bool {
+	for p := c; p != nil; p = p.parent {
+		if p.Hidden || !p.available() {
+			return false
+		}
+	}
+	return true
+}
+
+// Markdown returns the Markdown document of a Command.
+func (c *Command) markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", c.longName())
+	if short := c.title(c.Short); short != "" {
+		fmt.Fprintf(&b, "%s\n\n", short)
+	}
+
+	if !c.isTopic() {
+		fmt.Fprintf(&b, "## Usage\n\n```\n%s\n```\n\n", c.longUsage())
+	}
+
+	if long := strings.TrimSpace(c.Long); long != "" {
+		fmt.Fprintf(&b, "%s\n\n", long)
+	}
+
+	fs, done := c.definedFlags()
+	defer done()
+	if lines := flagLines(fs, nil, c.flagValues, c.shorthands); len(lines) > 0 {
+		fmt.Fprintf(&b, "## Flags\n\n")
+		for _, ln := range lines {
+			fmt.Fprintf(&b, "- `%s`: %s\n", ln.names, ln.usage)
+		}
+		fmt.Fprintf(&b, "\n")
+	}
+
+	if ex := strings.TrimSpace(c.Examples); ex != "" {
+		fmt.Fprintf(&b, "## Examples\n\n```\n%s\n```\n\n", ex)
+	}
+
+	var cmds, topics []*Command
+	for _, n := range c.children() {
+		child, ok := c.child(n)
+		if !ok || child.Hidden || !child.available() {
+			continue
+		}
+		if child.isTopic() {
+			topics = append(topics, child)
+			continue
+		}
+		cmds = append(cmds, child)
+	}
+	markdownList(&b, "Commands", cmds)
+	markdownList(&b, "Help topics", topics)
+
+	if c.parent != nil {
+		fmt.Fprintf(&b, "See also [%s](%s).\n", c.parent.longName(), c.parent.markdownFile())
+	}
+	return strings.TrimSpace(b.String()) + "\n"
+}
+
+// MarkdownFile returns the name of the Markdown file
+// of a Command.
+func (c *Command) markdownFile() string {
+	return strings.ReplaceAll(c.longName(), " ", "-") + ".md"
+}
+
+// MarkdownList writes a section
+// with a list of links to commands.
+func markdownList(b *strings.Builder, header string, cmds []*Command) {
+	if len(cmds) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "## %s\n\n", header)
+	for _, c := range cmds {
+		fmt.Fprintf(b, "- [%s](%s): %s\n", c.name(), c.markdownFile(), c.Short)
+	}
+	fmt.Fprintf(b, "\n")
+}
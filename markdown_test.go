@@ -0,0 +1,109 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package command_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/js-arias/command"
+)
+
+var appMarkdown = "# app\n\n" +
+	"App is an app for testing\n\n" +
+	"## Usage\n\n```\napp <command> [<argument>...]\n```\n\n" +
+	"## Commands\n\n" +
+	"- [cmd](app-cmd.md): a collection of commands\n" +
+	"- [error](app-error.md): always return an error\n" +
+	"- [hello](app-hello.md): print a hello message\n\n" +
+	"## Help topics\n\n" +
+	"- [topic](app-topic.md): a help topic\n"
+
+var helloMarkdown = "# app hello\n\n" +
+	"Print a hello message\n\n" +
+	"## Usage\n\n```\napp hello [--utf8] [--message <message>]\n```\n\n" +
+	"Command hello prints the well known \"hello, world\" message, or if --message\n" +
+	"flag is defined, a personalized hello message.\n\n" +
+	"## Flags\n\n" +
+	"- `--message string`: sets the greeting message (default \"world\")\n" +
+	"- `--utf8`: print an utf8 message\n\n" +
+	"See also [app](app.md).\n"
+
+var indexMarkdown = "# app\n\n" +
+	"App is an app for testing\n\n" +
+	"- [app](app.md): app is an app for testing\n" +
+	"- [app cmd](app-cmd.md): a collection of commands\n" +
+	"- [app cmd cat](app-cmd-cat.md): print stdin\n" +
+	"- [app cmd echo](app-cmd-echo.md): print its arguments\n" +
+	"- [app cmd error](app-cmd-error.md): always return an error\n" +
+	"- [app error](app-error.md): always return an error\n" +
+	"- [app hello](app-hello.md): print a hello message\n" +
+	"- [app topic](app-topic.md): a help topic\n"
+
+func TestGenMarkdownTree(t *testing.T) {
+	dir := t.TempDir()
+	app := newApp()
+	if err := app.GenMarkdownTree(dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := map[string]string{
+		"index.md":     indexMarkdown,
+		"app.md":       appMarkdown,
+		"app-hello.md": helloMarkdown,
+	}
+	for file, want := range tests {
+		b, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := string(b); got != want {
+			t.Errorf("%s:\n%s\nwant:\n%s", file, got, want)
+		}
+	}
+}
+
+func TestGenMarkdownTreeFromRun(t *testing.T) {
+	dir := t.TempDir()
+	app := newApp()
+	var verbose bool
+	app.SetFlags = func(c *command.Command) {
+		c.PersistentFlags().BoolVar(&verbose, "verbose", false, "print more information")
+	}
+	greet := &command.Command{
+		Usage: "greet [-n|--name <name>]",
+		Short: "print a greeting",
+		Run:   func(c *command.Command, args []string) error { return nil },
+		SetFlags: func(c *command.Command) {
+			c.StringFlag(new(string), "name", "n", "world", "name to greet")
+		},
+	}
+	app.Add(greet)
+	var gen string
+	app.Add(&command.Command{
+		Usage: "docs [--gen <format>]",
+		Short: "generate the documentation",
+		Run: func(c *command.Command, args []string) error {
+			if err := c.Root().GenMarkdownTree(dir); err != nil {
+				return err
+			}
+			fmt.Fprintf(c.Stdout(), "gen: %s verbose: %v\n", gen, verbose)
+			return nil
+		},
+		SetFlags: func(c *command.Command) {
+			c.Flags().StringVar(&gen, "gen", "md", "documentation format")
+		},
+	})
+
+	testExecute(t, app, []string{"--verbose", "docs", "--gen", "man"}, "", "gen: man verbose: true", "")
+
+	// the flags of commands not executed
+	// are not kept
+	if greet.Flags() != nil || greet.PersistentFlags() != nil {
+		t.Errorf("greet: flags defined after generating the documentation")
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package command
+
+// Messages are the texts
+// used by the package
+// to print the help
+// and the error messages,
+// for example,
+// to translate them into another language.
+//
+// Some messages are templates
+// used with fmt.Sprintf,
+// in that case,
+// the expected verbs are indicated.
+// Empty fields take the default English text.
+type Messages struct {
+	// UnknownCommand is the error message
+	// of an unknown command
+	// (default "unknown command").
+	UnknownCommand string
+
+	// UnknownHelpTopic is the error message
+	// of an unknown help topic
+	// (default "unknown help topic").
+	UnknownHelpTopic string
+
+	// Usage is the usage line
+	// printed after an usage error,
+	// with the usage of the command as %s
+	// (default "usage: %s").
+	Usage string
+
+	// Details is the line printed by Main
+	// after an usage error,
+	// with the help path of the command as %q
+	// (default "Run %q for details.").
+	Details string
+
+	// UsageHeader, FlagsHeader, GlobalFlagsHeader,
+	// and ExamplesHeader
+	// are the headers of the sections of the help
+	// (default "Usage:", "Flags:", "Global Flags:",
+	// and "Examples:").
+	UsageHeader       string
+	FlagsHeader       string
+	GlobalFlagsHeader string
+	ExamplesHeader    string
+
+	// CommandsHeader is the header of the list
+	// of children commands
+	// (default "The commands are:").
+	CommandsHeader string
+
+	// CommandsHelp is the line printed
+	// after the list of children commands,
+	// with the help path of the commands as %q
+	// (default "Use %q for more information about a command.").
+	CommandsHelp string
+
+	// TopicsHeader is the header of the list
+	// of help topics
+	// (default "Additional help topics:").
+	TopicsHeader string
+
+	// TopicsHelp is the line printed
+	// after the list of help topics,
+	// with the help path of the topics as %q
+	// (default "Use %q for more information about that topic.").
+	TopicsHelp string
+}
+
+var defaultMessages = Messages{
+	UnknownCommand:    "unknown command",
+	UnknownHelpTopic:  "unknown help topic",
+	Usage:             "usage: %s",
+	Details:           "Run %q for details.",
+	UsageHeader:       "Usage:",
+	FlagsHeader:       "Flags:",
+	GlobalFlagsHeader: "Global Flags:",
+	ExamplesHeader:    "Examples:",
+	CommandsHeader:    "The commands are:",
+	CommandsHelp:      "Use %q for more information about a command.",
+	TopicsHeader:      "Additional help topics:",
+	TopicsHelp:        "Use %q for more information about that topic.",
+}
+
+// Messages returns the messages
+// defined in the Command or its ancestors,
+// using the default messages
+// for the undefined fields.
+func (c *Command) messages() Messages {
+	m := defaultMessages
+	var set *Messages
+	for p := c; p != nil; p = p.parent {
+		if p.Messages != nil {
+			set = p.Messages
+			break
+		}
+	}
+	if set == nil {
+		return m
+	}
+
+	fields := []struct {
+		dst *string
+		src string
+	}{
+		{&m.UnknownCommand, set.UnknownCommand},
+		{&m.UnknownHelpTopic, set.UnknownHelpTopic},
+		{&m.Usage, set.Usage},
+		{&m.Details, set.Details},
+		{&m.UsageHeader, set.UsageHeader},
+		{&m.FlagsHeader, set.FlagsHeader},
+		{&m.GlobalFlagsHeader, set.GlobalFlagsHeader},
+		{&m.ExamplesHeader, set.ExamplesHeader},
+		{&m.CommandsHeader, set.CommandsHeader},
+		{&m.CommandsHelp, set.CommandsHelp},
+		{&m.TopicsHeader, set.TopicsHeader},
+		{&m.TopicsHelp, set.TopicsHelp},
+	}
+	for _, f := range fields {
+		if f.src != "" {
+			*f.dst = f.src
+		}
+	}
+	return m
+}
@@ -0,0 +1,41 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// PagedHelp prints the help of the Command
+// in the standard output.
+// If UsePager is set in the root Command,
+// the standard output is a terminal,
+// and the PAGER environment variable is defined,
+// the help is printed through the pager.
+// If the pager can not be started,
+// the help is printed directly.
+func (c *Command) pagedHelp() {
+	w := c.Stdout()
+	pager := strings.Fields(os.Getenv("PAGER"))
+	if !c.root().UsePager || len(pager) == 0 || !isTerminal(w) {
+		help(w, c)
+		return
+	}
+
+	var b bytes.Buffer
+	help(&b, c)
+	cmd := exec.Command(pager[0], pager[1:]...)
+	cmd.Stdin = &b
+	cmd.Stdout = w
+	cmd.Stderr = c.Stderr()
+	if err := cmd.Start(); err != nil {
+		w.Write(b.Bytes())
+		return
+	}
+	cmd.Wait()
+}
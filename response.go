@@ -0,0 +1,115 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// MaxResponseDepth is the maximum number
+// of nested response files.
+const maxResponseDepth = 10
+
+// ExpandResponseFiles replaces the arguments
+// that start with '@'
+// with the arguments read from the named file.
+// Arguments after a "--" terminator
+// are not expanded.
+func (c *Command) expandResponseFiles(args []string) ([]string, error) {
+	out, _, err := c.expandResponse(args, 0)
+	return out, err
+}
+
+// ExpandResponse expands the response files in args
+// at the given nesting depth.
+// It returns true
+// if the arguments contain a "--" terminator.
+func (c *Command) expandResponse(args []string, depth int) ([]string, bool, error) {
+	var out []string
+	for i, a := range args {
+		if a == "--" {
+			return append(out, args[i:]...), true, nil
+		}
+		if len(a) < 2 || a[0] != '@' {
+			out = append(out, a)
+			continue
+		}
+		if depth >= maxResponseDepth {
+			return nil, false, c.UsageError(fmt.Sprintf("response file %q: too many nested response files", a[1:]))
+		}
+		b, err := os.ReadFile(a[1:])
+		if err != nil {
+			return nil, false, c.UsageError(fmt.Sprintf("reading response file: %v", err))
+		}
+		fileArgs, err := splitArgs(string(b))
+		if err != nil {
+			return nil, false, c.UsageError(fmt.Sprintf("response file %q: %v", a[1:], err))
+		}
+		exp, terminated, err := c.expandResponse(fileArgs, depth+1)
+		if err != nil {
+			return nil, false, err
+		}
+		out = append(out, exp...)
+		if terminated {
+			return append(out, args[i+1:]...), true, nil
+		}
+	}
+	return out, false, nil
+}
+
+// SplitArgs splits a text into arguments
+// separated by spaces.
+// Single and double quotes
+// can be used to include spaces in an argument,
+// and outside single quotes,
+// a backslash escapes the next character.
+func splitArgs(s string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+				continue
+			}
+			cur.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote %q", quote)
+	}
+	if escaped {
+		return nil, fmt.Errorf("unterminated escape")
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,61 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package command_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/js-arias/command"
+)
+
+func TestResponseFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"args.txt":   "--message 'hello world'\n\"a b\" c\\ d @nested.txt",
+		"nested.txt": "e",
+		"loop.txt":   "@loop.txt",
+		"quote.txt":  "'unterminated",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.Chdir(wd)
+
+	newCmd := func() *command.Command {
+		var msg string
+		return &command.Command{
+			Usage:              "say [--message <message>] <argument>...",
+			AllowResponseFiles: true,
+			Run: func(c *command.Command, args []string) error {
+				fmt.Fprintf(c.Stdout(), "%s %q\n", msg, args)
+				return nil
+			},
+			SetFlags: func(c *command.Command) {
+				c.Flags().StringVar(&msg, "message", "", "")
+			},
+		}
+	}
+
+	testExecute(t, newCmd(), []string{"@args.txt", "f"}, "", `hello world ["a b" "c d" "e" "f"]`, "")
+	testExecute(t, newCmd(), []string{"--", "@args.txt"}, "", `["@args.txt"]`, "")
+	testExecuteError(t, newCmd(), []string{"@loop.txt"}, `say: response file "loop.txt": too many nested response files`)
+	testExecuteError(t, newCmd(), []string{"@quote.txt"}, `say: response file "quote.txt": unterminated quote '\''`)
+
+	c := newCmd()
+	c.AllowResponseFiles = false
+	testExecute(t, c, []string{"@args.txt"}, "", `["@args.txt"]`, "")
+}
@@ -0,0 +1,184 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+//
+// This work is derived from:
+//     * Cobra source code
+//       available at: https://github.com/spf13/cobra.
+//       Copyright 2013 Steve Francia.
+
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MaxSuggestions is the maximum number of suggestions
+// shown for an unknown command.
+const maxSuggestions = 3
+
+// Suggestions returns the names of the children commands
+// that are similar to name.
+// Aliases are also compared with name,
+// but only the canonical names are suggested.
+// If topics is true,
+// help topics are also suggested.
+func (c *Command) suggestions(name string, topics bool) []string {
+	name = c.fold(name)
+	if name == "" {
+		return nil
+	}
+
+	// best distance of each canonical name
+	best := make(map[string]int)
+	add := func(canon, n string) {
+		d := levenshtein(name, n)
+		if d > 2 && d > len(n)/3 {
+			return
+		}
+		if old, ok := best[canon]; ok && old <= d {
+			return
+		}
+		best[canon] = d
+	}
+
+	for _, n := range c.children() {
+		child, ok := c.child(n)
+		if !ok || child.Hidden || !child.available() {
+			continue
+		}
+		if !topics && child.isTopic() {
+			continue
+		}
+		add(n, n)
+		for _, a := range child.Aliases {
+			add(n, c.fold(a))
+		}
+	}
+	if !topics && !c.root().DisableAutoHelp {
+		if _, ok := c.child(c.helpName()); !ok {
+			h := c.fold(c.helpName())
+			add(h, h)
+		}
+	}
+
+	type candidate struct {
+		name string
+		dist int
+	}
+	candidates := make([]candidate, 0, len(best))
+	for n, d := range best {
+		candidates = append(candidates, candidate{name: n, dist: d})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].dist != candidates[j].dist {
+			return candidates[i].dist < candidates[j].dist
+		}
+		return candidates[i].name < candidates[j].name
+	})
+	if len(candidates) > maxSuggestions {
+		candidates = candidates[:maxSuggestions]
+	}
+
+	names := make([]string, 0, len(candidates))
+	for _, cn := range candidates {
+		names = append(names, cn.name)
+	}
+	return names
+}
+
+// PrefixMatch returns the child command
+// with name as an unambiguous prefix.
+// If prefix matching is not allowed,
+// or no command matches,
+// it returns nil.
+// If the prefix is ambiguous,
+// it returns an usage error.
+func (c *Command) prefixMatch(name string) (*Command, error) {
+	if !c.root().AllowPrefixMatch {
+		return nil, nil
+	}
+	prefix := c.fold(name)
+	if prefix == "" {
+		return nil, nil
+	}
+
+	var matches []string
+	for _, n := range c.children() {
+		child, ok := c.child(n)
+		if !ok || child.Hidden || !child.available() {
+			continue
+		}
+		if strings.HasPrefix(n, prefix) {
+			matches = append(matches, n)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, nil
+	case 1:
+		child, _ := c.child(matches[0])
+		return child, nil
+	}
+
+	q := make([]string, 0, len(matches))
+	for _, m := range matches {
+		q = append(q, fmt.Sprintf("%q", m))
+	}
+	return nil, usageError{
+		c:   c,
+		msg: fmt.Sprintf("%s %s: ambiguous command; could be one of %s", c.longName(), name, strings.Join(q, ", ")),
+	}
+}
+
+// DidYouMean returns a message
+// with the given suggestions.
+func didYouMean(suggestions []string) string {
+	if len(suggestions) == 0 {
+		return ""
+	}
+	if len(suggestions) == 1 {
+		return fmt.Sprintf("Did you mean %q?", suggestions[0])
+	}
+	q := make([]string, 0, len(suggestions))
+	for _, s := range suggestions {
+		q = append(q, fmt.Sprintf("%q", s))
+	}
+	return fmt.Sprintf("Did you mean one of %s?", strings.Join(q, ", "))
+}
+
+// Levenshtein returns the edit distance
+// between two strings.
+func levenshtein(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	prev := make([]int, len(t)+1)
+	cur := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		cur[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(t)]
+}
+
+func min3(a, b, c int) int {
+	m := a
+	if b < m {
+		m = b
+	}
+	if c < m {
+		m = c
+	}
+	return m
+}
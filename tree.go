@@ -0,0 +1,70 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package command
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// PrintTree prints the tree of commands
+// of the Command into w.
+// Each command is printed in its own line,
+// indented by its depth in the tree.
+// Hidden commands are not printed.
+//
+// MaxDepth is the maximum number of levels
+// of descendants to be printed.
+// If a command has children below the maximum depth,
+// its name is followed by an ellipsis.
+// If maxDepth is 0,
+// all descendants are printed.
+func (c *Command) PrintTree(w io.Writer, maxDepth int) error {
+	var b strings.Builder
+	c.printTree(&b, 0, maxDepth)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// PrintTree prints a command and its descendants
+// at the given depth.
+func (c *Command) printTree(b *strings.Builder, depth, maxDepth int) {
+	indent := strings.Repeat("    ", depth)
+	if maxDepth > 0 && depth == maxDepth && c.hasChildren() {
+		fmt.Fprintf(b, "%s%s ...\n", indent, c.name())
+		return
+	}
+	fmt.Fprintf(b, "%s%s\n", indent, c.name())
+
+	for _, n := range c.children() {
+		child, ok := c.child(n)
+		if !ok {
+			continue
+		}
+		if child.Hidden || !child.available() {
+			continue
+		}
+		child.printTree(b, depth+1, maxDepth)
+	}
+}
+
+// LeafCommands returns the long names
+// of all the descendants of the Command
+// that are runnable,
+// in lexicographic order.
+// Hidden commands are included.
+func (c *Command) LeafCommands() []string {
+	var leaves []string
+	c.Walk(func(cmd *Command) {
+		if cmd == c || !cmd.Runnable() {
+			return
+		}
+		leaves = append(leaves, cmd.longName())
+	})
+	sort.Strings(leaves)
+	return leaves
+}
@@ -0,0 +1,86 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+package command_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/js-arias/command"
+)
+
+var fullTree = `app
+    cmd
+        cat
+        echo
+        error
+    error
+    hello
+    topic`
+
+var shortTree = `app
+    cmd ...
+    error
+    hello
+    topic`
+
+func TestPrintTree(t *testing.T) {
+	tests := map[string]struct {
+		depth int
+		out   string
+	}{
+		"unlimited": {
+			out: fullTree,
+		},
+		"depth 1": {
+			depth: 1,
+			out:   shortTree,
+		},
+		"depth 2": {
+			depth: 2,
+			out:   fullTree,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			app := newApp()
+			var b strings.Builder
+			if err := app.PrintTree(&b, test.depth); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := strings.TrimSpace(b.String()); got != test.out {
+				t.Errorf("tree:\n%s\nwant:\n%s", got, test.out)
+			}
+		})
+	}
+}
+
+func TestWalk(t *testing.T) {
+	app := newApp()
+	var names []string
+	app.Walk(func(c *command.Command) {
+		names = append(names, c.Usage)
+	})
+	want := "app <command> [<argument>...]|cmd <command> [<argument>...]|cat|echo <argument>...|error <argument>...|error|hello [--utf8] [--message <message>]|topic"
+	if got := strings.Join(names, "|"); got != want {
+		t.Errorf("walk: got %q, want %q", got, want)
+	}
+}
+
+func TestLeafCommands(t *testing.T) {
+	app := newApp()
+	app.Add(&command.Command{
+		Usage:  "secret",
+		Run:    echoToStderrRun,
+		Hidden: true,
+	})
+
+	got := strings.Join(app.LeafCommands(), "\n")
+	want := "app cmd cat\napp cmd echo\napp cmd error\napp error\napp hello\napp secret"
+	if got != want {
+		t.Errorf("leaf commands: got\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,17 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+//go:build !linux && !darwin
+
+package command
+
+import "os"
+
+// TermWidth returns the number of columns
+// of the terminal f.
+// In this platform the size can not be determined,
+// so it always returns 0.
+func termWidth(f *os.File) int {
+	return 0
+}
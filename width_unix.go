@@ -0,0 +1,28 @@
+// Copyright © 2022 J. Salvador Arias <jsalarias@gmail.com>
+// All rights reserved.
+// Distributed under BSD2 license that can be found in the LICENSE file.
+
+//go:build linux || darwin
+
+package command
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// TermWidth returns the number of columns
+// of the terminal f.
+// It returns 0 if the size can not be determined.
+func termWidth(f *os.File) int {
+	var ws struct {
+		row, col       uint16
+		xpixel, ypixel uint16
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+	if errno != 0 {
+		return 0
+	}
+	return int(ws.col)
+}